@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"teleflix/internal/diff"
+	"teleflix/internal/generator"
+
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare les manifests générés à ceux déjà présents dans --output",
+	Long: `Génère les manifests en mémoire et les compare aux fichiers YAML ou JSON du
+répertoire de sortie. Les objets sont rapprochés par apiVersion, kind,
+namespace et nom ; chaque objet ajouté, supprimé ou modifié est affiché avec
+les champs concernés.
+
+Le code de sortie est non nul en cas de différence, pour bloquer une CI :
+
+  teleflix diff --config config.yaml --output ./manifests`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return diffManifests()
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&outputDir, "output", "o", "./manifests", "Répertoire des manifests existants")
+	diffCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace Kubernetes")
+	diffCmd.Flags().StringVarP(&storageClass, "storage-class", "s", "", "Classe de stockage")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func diffManifests() error {
+	cfg, err := prepareConfig()
+	if err != nil {
+		return err
+	}
+
+	manifests, err := generator.New(cfg).GenerateAll()
+	if err != nil {
+		return fmt.Errorf("erreur lors de la génération: %w", err)
+	}
+
+	var current []diff.Object
+	names := make([]string, 0, len(manifests))
+	for name := range manifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		objects, err := diff.Decode([]byte(manifests[name]), name+".yaml")
+		if err != nil {
+			return err
+		}
+		current = append(current, objects...)
+	}
+
+	previous, err := readManifests(outputDir)
+	if err != nil {
+		return err
+	}
+
+	changes := diff.Compare(previous, current)
+	if len(changes) == 0 {
+		fmt.Printf("✓ Aucune différence avec %s\n", outputDir)
+		return nil
+	}
+	if err := diff.Write(os.Stdout, changes); err != nil {
+		return err
+	}
+	return fmt.Errorf("les manifests de %s ne correspondent plus à la configuration", outputDir)
+}
+
+// readManifests décode les fichiers YAML et JSON présents dans dir.
+func readManifests(dir string) ([]diff.Object, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("lecture des manifests existants: %w", err)
+	}
+
+	var objects []diff.Object
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := diff.Decode(data, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, decoded...)
+	}
+	return objects, nil
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"teleflix/internal/config"
 	"teleflix/internal/generator"
+	"teleflix/internal/output"
 
 	"github.com/spf13/cobra"
 )
@@ -16,6 +18,20 @@ var (
 	outputDir    string
 	namespace    string
 	storageClass string
+	lenient      bool
+	format       string
+	overlays     []string
+	stdout       bool
+	prune        bool
+)
+
+// Formats de sortie supportés par --format
+const (
+	formatKubernetes = "kubernetes"
+	formatCompose    = "compose"
+	formatHelm       = "helm"
+	formatKustomize  = "kustomize"
+	formatJSON       = "json"
 )
 
 var rootCmd = &cobra.Command{
@@ -25,14 +41,22 @@ var rootCmd = &cobra.Command{
 de streaming avec Jellyfin, Sonarr, Radarr, Jackett et qBittorrent.
 
 Utilise un fichier de configuration YAML pour personnaliser tous les services.`,
+	// Les erreurs sont affichées par main, sans rappel de l'usage
+	SilenceUsage:  true,
+	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return generateManifests()
 	},
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&configFile, "config", "c", "config.yaml", "Fichier de configuration")
-	rootCmd.Flags().StringVarP(&outputDir, "output", "o", "./manifests", "Répertoire de sortie")
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "config.yaml", "Fichier de configuration")
+	rootCmd.PersistentFlags().BoolVar(&lenient, "lenient", false, "Ignore les clés inconnues du fichier de configuration")
+	rootCmd.Flags().StringVarP(&outputDir, "output", "o", "./manifests", "Répertoire de sortie (- pour la sortie standard)")
+	rootCmd.Flags().BoolVar(&prune, "prune", false, "Supprime aussi les anciens manifests non suivis par "+output.LockFile)
+	rootCmd.Flags().BoolVar(&stdout, "stdout", false, "Écrit un flux YAML unique sur la sortie standard (équivaut à --output -)")
+	rootCmd.Flags().StringVarP(&format, "format", "f", formatKubernetes, "Format de sortie (kubernetes, compose, helm, kustomize, json)")
+	rootCmd.Flags().StringArrayVar(&overlays, "overlay", nil, "Overlay kustomize nom=fichier (répétable, avec --format kustomize)")
 	rootCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace Kubernetes")
 	rootCmd.Flags().StringVarP(&storageClass, "storage-class", "s", "", "Classe de stockage")
 }
@@ -41,11 +65,17 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
-func generateManifests() error {
-	// Charger la configuration
-	cfg, err := config.Load(configFile)
+// loadConfig charge le fichier de configuration en respectant --lenient.
+func loadConfig() (*config.Config, error) {
+	return config.LoadWithOptions(configFile, config.LoadOptions{Lenient: lenient})
+}
+
+// prepareConfig charge la configuration, applique les surcharges des flags
+// et la valide.
+func prepareConfig() (*config.Config, error) {
+	cfg, err := loadConfig()
 	if err != nil {
-		return fmt.Errorf("erreur lors du chargement de la configuration: %w", err)
+		return nil, fmt.Errorf("erreur lors du chargement de la configuration: %w", err)
 	}
 
 	// Override avec les flags
@@ -56,30 +86,163 @@ func generateManifests() error {
 		cfg.StorageClass = storageClass
 	}
 
-	// Créer le répertoire de sortie
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("erreur lors de la création du répertoire: %w", err)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func generateManifests() error {
+	// Charger et valider la configuration avant toute écriture
+	cfg, err := prepareConfig()
+	if err != nil {
+		return err
+	}
+
+	// Flux unique sur la sortie standard, ex: teleflix | kubectl apply -f -
+	if stdout || outputDir == "-" {
+		if format != formatKubernetes && format != formatCompose && format != formatJSON {
+			return fmt.Errorf("le format %s produit plusieurs fichiers et ne peut pas être écrit sur la sortie standard", format)
+		}
+		gen := generator.New(cfg)
+		manifests, err := render(gen)
+		if err != nil {
+			return fmt.Errorf("erreur lors de la génération: %w", err)
+		}
+		if err := printWarnings(gen); err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(os.Stdout, generator.Stream(manifests))
+		return err
 	}
 
 	// Générer les manifests
 	gen := generator.New(cfg)
-	manifests, err := gen.GenerateAll()
+	manifests, err := render(gen)
 	if err != nil {
 		return fmt.Errorf("erreur lors de la génération: %w", err)
 	}
+	if err := printWarnings(gen); err != nil {
+		return err
+	}
 
-	// Écrire les fichiers
+	ext := ".yaml"
+	if format == formatJSON {
+		ext = ".json"
+	}
+	files := make(map[string]string, len(manifests))
 	for name, content := range manifests {
-		filePath := filepath.Join(outputDir, name+".yaml")
-		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
-			return fmt.Errorf("erreur lors de l'écriture de %s: %w", filePath, err)
-		}
-		fmt.Printf("✓ Généré: %s\n", filePath)
+		files[name+ext] = content
+	}
+
+	// Écrire les fichiers et supprimer ceux de la génération précédente
+	result, err := output.Sync(outputDir, files, prune)
+	if err != nil {
+		return err
+	}
+	for _, name := range result.Written {
+		fmt.Printf("✓ Généré: %s\n", filepath.Join(outputDir, filepath.FromSlash(name)))
+	}
+	for _, name := range result.Removed {
+		fmt.Printf("✗ Supprimé: %s\n", filepath.Join(outputDir, filepath.FromSlash(name)))
+	}
+	for _, name := range result.Untracked {
+		fmt.Fprintf(os.Stderr, "⚠ Fichier obsolète conservé: %s (utiliser --prune pour le supprimer)\n",
+			filepath.Join(outputDir, filepath.FromSlash(name)))
 	}
 
 	fmt.Printf("\n🎉 Tous les manifests ont été générés dans %s\n", outputDir)
 	fmt.Println("\nPour déployer:")
-	fmt.Printf("kubectl apply -f %s/\n", outputDir)
+	switch format {
+	case formatCompose:
+		fmt.Printf("docker compose -f %s up -d\n", filepath.Join(outputDir, "docker-compose.yaml"))
+	case formatHelm:
+		fmt.Printf("helm upgrade --install teleflix %s\n", outputDir)
+	case formatKustomize:
+		target := "base"
+		if len(overlays) > 0 {
+			name, _, _ := strings.Cut(overlays[0], "=")
+			target = "overlays/" + name
+		}
+		fmt.Printf("kubectl apply -k %s\n", filepath.Join(outputDir, filepath.FromSlash(target)))
+	default:
+		fmt.Printf("kubectl apply -f %s/\n", outputDir)
+	}
+
+	return nil
+}
 
+// printWarnings affiche les avertissements du générateur sur la sortie
+// d'erreur. Les Pod Security Standards ne concernent pas docker-compose.
+func printWarnings(gen *generator.Generator) error {
+	var warnings []string
+	if format == formatCompose {
+		warnings = gen.ConfigWarnings()
+	} else {
+		all, err := gen.Warnings()
+		if err != nil {
+			return fmt.Errorf("erreur lors de la génération: %w", err)
+		}
+		warnings = all
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", w)
+	}
 	return nil
 }
+
+// render produit les fichiers correspondant au format demandé.
+func render(gen *generator.Generator) (map[string]string, error) {
+	if len(overlays) > 0 && format != formatKustomize {
+		return nil, fmt.Errorf("--overlay n'est utilisable qu'avec --format %s", formatKustomize)
+	}
+
+	switch format {
+	case formatKubernetes:
+		return gen.GenerateAll()
+	case formatCompose:
+		return gen.GenerateCompose()
+	case formatHelm:
+		return gen.GenerateHelmChart()
+	case formatJSON:
+		return gen.GenerateJSON()
+	case formatKustomize:
+		list, err := loadOverlays()
+		if err != nil {
+			return nil, err
+		}
+		return gen.GenerateKustomize(list)
+	default:
+		return nil, fmt.Errorf("format de sortie inconnu: %s (formats possibles: %s, %s, %s, %s, %s)",
+			format, formatKubernetes, formatCompose, formatHelm, formatKustomize, formatJSON)
+	}
+}
+
+// loadOverlays charge et valide les configurations passées via --overlay.
+func loadOverlays() ([]generator.Overlay, error) {
+	var list []generator.Overlay
+	seen := make(map[string]bool)
+	for _, spec := range overlays {
+		name, file, ok := strings.Cut(spec, "=")
+		if !ok || name == "" || file == "" {
+			return nil, fmt.Errorf("overlay invalide: %q (format attendu: nom=fichier)", spec)
+		}
+		if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			return nil, fmt.Errorf("nom d'overlay invalide: %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("overlay %s déclaré plusieurs fois", name)
+		}
+		seen[name] = true
+
+		cfg, err := config.LoadWithOptions(file, config.LoadOptions{Lenient: lenient})
+		if err != nil {
+			return nil, fmt.Errorf("erreur lors du chargement de l'overlay %s: %w", name, err)
+		}
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+		list = append(list, generator.Overlay{Name: name, Config: cfg})
+	}
+	return list, nil
+}
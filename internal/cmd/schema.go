@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+
+	"teleflix/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Affiche le JSON Schema du fichier de configuration",
+	Long: `Écrit sur la sortie standard le JSON Schema de config.yaml : types,
+valeurs possibles (type d'issuer, modes d'accès...) et valeurs par défaut.
+À référencer depuis l'éditeur pour l'autocomplétion, par exemple :
+
+  teleflix schema > teleflix.schema.json
+  # yaml-language-server: $schema=./teleflix.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := config.JSONSchema()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
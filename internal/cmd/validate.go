@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"teleflix/internal/generator"
+
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Valide le fichier de configuration sans générer de manifests",
+	Long: `Vérifie le fichier de configuration (quantités Kubernetes, ports, noms RFC 1123,
+modes d'accès, cert-manager...) et affiche toutes les erreurs trouvées avec
+leur position dans le fichier YAML, puis contrôle les pods générés au regard
+des Pod Security Standards configurés (podSecurity).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return validateConfig()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+func validateConfig() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("erreur lors du chargement de la configuration: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	// Un pod refusé par podSecurity.enforce fait échouer la génération
+	warnings, err := generator.New(cfg).Warnings()
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", w)
+	}
+
+	fmt.Printf("✓ Configuration valide: %s\n", configFile)
+	return nil
+}
@@ -0,0 +1,43 @@
+package compose
+
+// Types du format Compose Specification (docker compose v2)
+type Project struct {
+	Name     string             `yaml:"name,omitempty"`
+	Services map[string]Service `yaml:"services"`
+	Volumes  map[string]Volume  `yaml:"volumes,omitempty"`
+	Networks map[string]Network `yaml:"networks,omitempty"`
+}
+
+type Service struct {
+	Image       string            `yaml:"image"`
+	Restart     string            `yaml:"restart,omitempty"`
+	Environment map[string]string `yaml:"environment,omitempty"`
+	Ports       []string          `yaml:"ports,omitempty"`
+	Volumes     []string          `yaml:"volumes,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Networks    []string          `yaml:"networks,omitempty"`
+	Deploy      *Deploy           `yaml:"deploy,omitempty"`
+}
+
+type Deploy struct {
+	Resources Resources `yaml:"resources"`
+}
+
+type Resources struct {
+	Limits       *ResourceValues `yaml:"limits,omitempty"`
+	Reservations *ResourceValues `yaml:"reservations,omitempty"`
+}
+
+type ResourceValues struct {
+	CPUs   string `yaml:"cpus,omitempty"`
+	Memory string `yaml:"memory,omitempty"`
+}
+
+type Volume struct {
+	Driver     string            `yaml:"driver,omitempty"`
+	DriverOpts map[string]string `yaml:"driver_opts,omitempty"`
+}
+
+type Network struct {
+	External bool `yaml:"external,omitempty"`
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"bytes"
 	"os"
+	"reflect"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,28 +13,178 @@ type Config struct {
 	StorageClass string `yaml:"storageClass"`
 	Domain       string `yaml:"domain"`
 
-	Services struct {
-		Jellyfin    ServiceConfig `yaml:"jellyfin"`
-		Sonarr      ServiceConfig `yaml:"sonarr"`
-		Radarr      ServiceConfig `yaml:"radarr"`
-		Jackett     ServiceConfig `yaml:"jackett"`
-		QBittorrent ServiceConfig `yaml:"qbittorrent"`
-	} `yaml:"services"`
+	Services ServiceCatalog `yaml:"services"`
 
-	Storage     StorageConfig     `yaml:"storage"`
-	Ingress     IngressConfig     `yaml:"ingress"`
-	CertManager CertManagerConfig `yaml:"certManager"`
+	Storage       StorageConfig       `yaml:"storage"`
+	PodSecurity   PodSecurityConfig   `yaml:"podSecurity"`
+	NetworkPolicy NetworkPolicyConfig `yaml:"networkPolicy"`
+	Ingress       IngressConfig       `yaml:"ingress"`
+	CertManager   CertManagerConfig   `yaml:"certManager"`
+	Compose       ComposeConfig       `yaml:"compose"`
+
+	// Fichier source et arbre YAML, utilisés pour localiser les erreurs de validation
+	file   string
+	source *yaml.Node
 }
 
 type ServiceConfig struct {
+	Preset      string            `yaml:"preset,omitempty"` // Preset intégré servant de base (par défaut : le nom du service)
 	Enabled     bool              `yaml:"enabled"`
 	Exposed     bool              `yaml:"exposed"` // Nouveau : contrôle l'exposition via ingress
 	Image       string            `yaml:"image"`
 	Tag         string            `yaml:"tag"`
-	Port        int32             `yaml:"port"`
+	Port        int32             `yaml:"port"`               // Port principal (interface web)
+	Ports       []PortConfig      `yaml:"ports,omitempty"`    // Ports supplémentaires
+	Replicas    *int32            `yaml:"replicas,omitempty"` // Nombre de pods (1 par défaut)
+	Strategy    string            `yaml:"strategy,omitempty"` // Recreate ou RollingUpdate (défaut : selon les volumes)
 	Resources   ResourcesConfig   `yaml:"resources"`
 	Environment map[string]string `yaml:"environment"`
 	Volumes     []VolumeConfig    `yaml:"volumes"`
+	Links       []LinkConfig      `yaml:"links,omitempty"`   // Services du catalogue utilisés par celui-ci
+	Seed        *SeedConfig       `yaml:"seed,omitempty"`    // Fichier de configuration écrit au premier démarrage
+	Service     KubeServiceConfig `yaml:"service,omitempty"` // Type et options du Service principal
+	Probes      ProbesConfig      `yaml:"probes,omitempty"`
+	Security    SecurityConfig    `yaml:"securityContext,omitempty"`
+
+	// Autorise les connexions sortantes vers Internet (hors plages privées)
+	// lorsque les NetworkPolicies sont générées
+	InternetAccess bool `yaml:"internetAccess,omitempty"`
+
+	VPN VPNConfig `yaml:"vpn,omitempty"`
+}
+
+// SeedConfig décrit un fichier de configuration initial, écrit dans un volume
+// du service avant son premier démarrage ; un fichier existant n'est jamais
+// remplacé. Content est un template Go : {{ host "sonarr" }}, {{ port "sonarr" }},
+// {{ url "sonarr" }} et {{ if enabled "sonarr" }} désignent les services du
+// catalogue.
+type SeedConfig struct {
+	Volume  string `yaml:"volume"`  // Volume du service recevant le fichier, ex: config
+	File    string `yaml:"file"`    // Chemin relatif au volume, ex: settings.json
+	Content string `yaml:"content"` // Contenu du fichier
+}
+
+// ProbesConfig décrit la vérification de santé d'un service, commune aux
+// sondes liveness, readiness et startup ; seuls leurs délais diffèrent.
+type ProbesConfig struct {
+	Type      string      `yaml:"type,omitempty"` // "http", "tcp" ou "none" (vide : aucune sonde)
+	Path      string      `yaml:"path,omitempty"` // Chemin vérifié pour le type http, ex: /health
+	Port      int32       `yaml:"port,omitempty"` // Port vérifié (par défaut : port du service)
+	Liveness  ProbeTiming `yaml:"liveness,omitempty"`
+	Readiness ProbeTiming `yaml:"readiness,omitempty"`
+	Startup   ProbeTiming `yaml:"startup,omitempty"`
+}
+
+// KubeServiceConfig choisit le type du Service principal, par exemple
+// LoadBalancer (MetalLB) pour joindre Jellyfin sur le LAN sans l'ingress.
+type KubeServiceConfig struct {
+	Type                  string            `yaml:"type,omitempty"`                  // ClusterIP (défaut), NodePort ou LoadBalancer
+	NodePort              int32             `yaml:"nodePort,omitempty"`              // Port fixe sur les nœuds (30000-32767)
+	LoadBalancerIP        string            `yaml:"loadBalancerIP,omitempty"`        // IP demandée au load balancer
+	ExternalTrafficPolicy string            `yaml:"externalTrafficPolicy,omitempty"` // Cluster ou Local (conserve l'IP du client)
+	Annotations           map[string]string `yaml:"annotations,omitempty"`           // Ex: metallb.universe.tf/address-pool
+}
+
+// PortConfig déclare un port supplémentaire du service, par exemple le port
+// pair BitTorrent. Sans serviceType, il est ajouté au Service principal ;
+// les ports NodePort et LoadBalancer sont regroupés dans un Service dédié
+// (<service>-nodeport ou <service>-lb).
+type PortConfig struct {
+	Name        string `yaml:"name"` // 15 caractères max, unique dans le service
+	Port        int32  `yaml:"port"`
+	Protocol    string `yaml:"protocol,omitempty"`    // TCP (défaut) ou UDP
+	ServiceType string `yaml:"serviceType,omitempty"` // NodePort ou LoadBalancer
+	NodePort    int32  `yaml:"nodePort,omitempty"`    // 30000-32767, attribué par Kubernetes si vide
+	Env         string `yaml:"env,omitempty"`         // Variable recevant le numéro du port
+}
+
+// VPNConfig ajoute au pod un sidecar gluetun par lequel passe tout le
+// trafic du service (typiquement qBittorrent). Son pare-feu bloque toute
+// connexion hors du tunnel : si le VPN tombe, le service est coupé.
+type VPNConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Image      string `yaml:"image,omitempty"`      // Par défaut : qmcgaw/gluetun:latest
+	Provider   string `yaml:"provider"`             // VPN_SERVICE_PROVIDER, ex: mullvad, protonvpn, airvpn, custom
+	Type       string `yaml:"type,omitempty"`       // wireguard (défaut) ou openvpn
+	SecretName string `yaml:"secretName,omitempty"` // Identifiants (WIREGUARD_PRIVATE_KEY...), par défaut <service>-vpn
+
+	// Redirection de port pour le port torrent : port fixe attribué par le
+	// fournisseur (AirVPN...), ou port dynamique négocié par gluetun
+	// (ProtonVPN, PIA) et reporté dans qBittorrent via son API
+	ForwardedPort  int32 `yaml:"forwardedPort,omitempty"`
+	PortForwarding bool  `yaml:"portForwarding,omitempty"`
+
+	// Plages joignables hors du tunnel malgré le kill switch, par défaut les
+	// pods et Services de k3s (10.42.0.0/16, 10.43.0.0/16)
+	OutboundSubnets []string `yaml:"outboundSubnets,omitempty"`
+
+	// Monte le périphérique /dev/net/tun de l'hôte (hostPath) au lieu de
+	// laisser gluetun le créer dans le conteneur
+	HostTun bool `yaml:"hostTun,omitempty"`
+
+	Environment map[string]string `yaml:"environment,omitempty"` // Variables gluetun supplémentaires, ex: SERVER_COUNTRIES
+}
+
+// NetworkPolicyConfig active l'isolation réseau des pods : tout est refusé
+// sauf le DNS, les liens entre services, l'accès depuis le contrôleur
+// d'ingress aux services exposés et Internet pour les services qui le
+// déclarent (internetAccess). Désactivée par défaut : le namespace du
+// contrôleur d'ingress dépend du cluster et doit être renseigné.
+type NetworkPolicyConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	IngressNamespace string `yaml:"ingressNamespace,omitempty"` // Namespace du contrôleur d'ingress, ex: kube-system pour Traefik sur k3s
+
+	// Pods du DNS du cluster, par défaut CoreDNS (k8s-app: kube-dns dans
+	// kube-system) ; à adapter pour NodeLocal DNSCache ou un DNS déployé ailleurs
+	DNSNamespace string            `yaml:"dnsNamespace,omitempty"`
+	DNSSelector  map[string]string `yaml:"dnsSelector,omitempty"`
+}
+
+// PodSecurityConfig fixe les niveaux des Pod Security Standards (privileged,
+// baseline ou restricted) appliqués au namespace. La génération échoue si un
+// pod ne respecte pas le niveau enforce ; les niveaux audit et warn ne
+// produisent que des avertissements. Un niveau vide n'ajoute pas de label.
+type PodSecurityConfig struct {
+	Enforce string `yaml:"enforce,omitempty"`
+	Audit   string `yaml:"audit,omitempty"`
+	Warn    string `yaml:"warn,omitempty"`
+	Version string `yaml:"version,omitempty"` // Version des règles, ex: v1.30 (défaut : latest)
+}
+
+// SecurityConfig contrôle le securityContext des pods d'un service. Les
+// valeurs par défaut sont durcies : utilisateur non root issu de PUID/PGID,
+// toutes les capabilities retirées, seccomp RuntimeDefault et élévation de
+// privilèges interdite.
+type SecurityConfig struct {
+	RunAsUser                *int64             `yaml:"runAsUser,omitempty"`              // Par défaut : variable PUID
+	RunAsGroup               *int64             `yaml:"runAsGroup,omitempty"`             // Par défaut : variable PGID
+	FSGroup                  *int64             `yaml:"fsGroup,omitempty"`                // Par défaut : runAsGroup
+	ReadOnlyRootFilesystem   bool               `yaml:"readOnlyRootFilesystem,omitempty"` // /tmp reste inscriptible (emptyDir)
+	AllowPrivilegeEscalation bool               `yaml:"allowPrivilegeEscalation,omitempty"`
+	Capabilities             CapabilitiesConfig `yaml:"capabilities,omitempty"`
+	SeccompProfile           string             `yaml:"seccompProfile,omitempty"` // RuntimeDefault (défaut) ou Unconfined
+}
+
+type CapabilitiesConfig struct {
+	Add  []string `yaml:"add,omitempty"`
+	Drop []string `yaml:"drop,omitempty"` // Par défaut : ALL
+}
+
+// ProbeTiming surcharge les délais d'une sonde ; un champ nul garde la
+// valeur par défaut du générateur.
+type ProbeTiming struct {
+	InitialDelaySeconds int32 `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int32 `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      int32 `yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold    int32 `yaml:"failureThreshold,omitempty"`
+}
+
+// LinkConfig déclare une dépendance vers un autre service du catalogue.
+// Si Env est renseigné, l'URL interne du service cible y est injectée.
+type LinkConfig struct {
+	Service  string `yaml:"service"`
+	Env      string `yaml:"env,omitempty"`
+	Optional bool   `yaml:"optional,omitempty"` // Ignoré si le service est absent du catalogue
 }
 
 type ResourcesConfig struct {
@@ -51,17 +203,95 @@ type VolumeConfig struct {
 	MountPath string `yaml:"mountPath"`
 	Size      string `yaml:"size"`
 	ReadOnly  bool   `yaml:"readOnly"`
+
+	// Options du PVC généré pour le volume
+	StorageClass string            `yaml:"storageClass,omitempty"` // Défaut : storageClass globale
+	AccessModes  []string          `yaml:"accessModes,omitempty"`  // Défaut : ReadWriteOnce
+	VolumeMode   string            `yaml:"volumeMode,omitempty"`   // Filesystem (défaut) ou Block, monté en périphérique à mountPath
+	Annotations  map[string]string `yaml:"annotations,omitempty"`  // Ex: nombre de réplicas Longhorn
+
+	VolumeSourceConfig `yaml:",inline"`
+}
+
+// VolumeSourceConfig choisit l'origine d'un volume ; sans source, un PVC est
+// provisionné par la storageClass. Au plus une source peut être définie.
+type VolumeSourceConfig struct {
+	ExistingClaim string     `yaml:"existingClaim,omitempty"` // PVC existant, non généré
+	EmptyDir      bool       `yaml:"emptyDir,omitempty"`      // Volume temporaire, vidé à chaque redémarrage du pod
+	HostPath      string     `yaml:"hostPath,omitempty"`      // Dossier du nœud
+	NFS           *NFSConfig `yaml:"nfs,omitempty"`
+	SMB           *SMBConfig `yaml:"smb,omitempty"` // Partage SMB/CIFS (driver CSI smb.csi.k8s.io)
+
+	// Monte hostPath ou nfs par un PersistentVolume statique lié à un PVC
+	// plutôt que directement dans le pod. Toujours le cas pour smb et pour
+	// les volumes partagés (storage.*).
+	PersistentVolume bool `yaml:"persistentVolume,omitempty"`
+}
+
+type NFSConfig struct {
+	Server string `yaml:"server"`
+	Path   string `yaml:"path"`
+}
+
+type SMBConfig struct {
+	Source       string   `yaml:"source"`                 // //serveur/partage
+	SecretName   string   `yaml:"secretName"`             // Secret contenant username et password
+	MountOptions []string `yaml:"mountOptions,omitempty"` // Ex: uid=1000, gid=1000, dir_mode=0775
+}
+
+// Sources retourne le nom des sources définies, dans l'ordre des champs.
+func (s VolumeSourceConfig) Sources() []string {
+	var sources []string
+	if s.ExistingClaim != "" {
+		sources = append(sources, "existingClaim")
+	}
+	if s.EmptyDir {
+		sources = append(sources, "emptyDir")
+	}
+	if s.HostPath != "" {
+		sources = append(sources, "hostPath")
+	}
+	if s.NFS != nil {
+		sources = append(sources, "nfs")
+	}
+	if s.SMB != nil {
+		sources = append(sources, "smb")
+	}
+	return sources
+}
+
+// Inline indique si la source est montée directement dans le pod, sans PVC.
+func (s VolumeSourceConfig) Inline() bool {
+	return s.EmptyDir || (!s.PersistentVolume && (s.HostPath != "" || s.NFS != nil))
+}
+
+// Static indique si la source est exposée par un PersistentVolume statique.
+func (s VolumeSourceConfig) Static() bool {
+	return !s.Inline() && (s.HostPath != "" || s.NFS != nil || s.SMB != nil)
 }
 
+// StorageConfig décrit les volumes partagés. La disposition "split" (défaut)
+// crée un PVC pour les médias et un pour les téléchargements ; "unified"
+// les regroupe dans un PVC data (sous-dossiers torrents/ et media/) monté
+// sous /data dans tous les services, ce qui permet aux *arr d'importer par
+// hardlink ou déplacement atomique.
 type StorageConfig struct {
-	Media struct {
-		Size        string   `yaml:"size"`
-		AccessModes []string `yaml:"accessModes"`
-	} `yaml:"media"`
-	Downloads struct {
-		Size        string   `yaml:"size"`
-		AccessModes []string `yaml:"accessModes"`
-	} `yaml:"downloads"`
+	Layout    string             `yaml:"layout,omitempty"` // "split" (défaut) ou "unified"
+	Media     SharedVolumeConfig `yaml:"media"`
+	Downloads SharedVolumeConfig `yaml:"downloads"`
+	Data      SharedVolumeConfig `yaml:"data"` // Disposition unified uniquement
+}
+
+// SharedVolumeConfig décrit un PVC partagé entre les services. Une source
+// hostPath, nfs ou smb le lie à un PersistentVolume statique, pour monter
+// des données existantes (NAS...).
+type SharedVolumeConfig struct {
+	Size         string            `yaml:"size"`
+	AccessModes  []string          `yaml:"accessModes"`
+	StorageClass string            `yaml:"storageClass,omitempty"` // Défaut : storageClass globale
+	Annotations  map[string]string `yaml:"annotations,omitempty"`
+
+	VolumeSourceConfig `yaml:",inline"`
 }
 
 type IngressConfig struct {
@@ -83,7 +313,34 @@ type CertManagerConfig struct {
 	} `yaml:"issuer"`
 }
 
+// ComposeConfig contrôle la sortie docker-compose (--format compose).
+type ComposeConfig struct {
+	MediaPath     string `yaml:"mediaPath"`     // Chemin hôte des médias (volume nommé si vide)
+	DownloadsPath string `yaml:"downloadsPath"` // Chemin hôte des téléchargements (volume nommé si vide)
+	DataPath      string `yaml:"dataPath"`      // Chemin hôte du volume data (disposition unified)
+	Restart       string `yaml:"restart"`
+	PublishPorts  bool   `yaml:"publishPorts"` // Publie le port de chaque service sur l'hôte
+	Traefik       struct {
+		Enabled      bool   `yaml:"enabled"`
+		EntryPoint   string `yaml:"entryPoint"`
+		CertResolver string `yaml:"certResolver"`
+		Network      string `yaml:"network"` // Réseau externe partagé avec Traefik
+	} `yaml:"traefik"`
+}
+
+// LoadOptions contrôle la façon dont le fichier de configuration est décodé.
+type LoadOptions struct {
+	// Lenient ignore les clés inconnues au lieu de les rejeter
+	// (compatibilité avec d'anciens fichiers de configuration).
+	Lenient bool
+}
+
+// Load charge la configuration en mode strict : toute clé inconnue est une erreur.
 func Load(filename string) (*Config, error) {
+	return LoadWithOptions(filename, LoadOptions{})
+}
+
+func LoadWithOptions(filename string, opts LoadOptions) (*Config, error) {
 	// Configuration par défaut
 	cfg := getDefaultConfig()
 
@@ -94,9 +351,32 @@ func Load(filename string) (*Config, error) {
 			return nil, err
 		}
 
-		if err := yaml.Unmarshal(data, cfg); err != nil {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
 			return nil, err
 		}
+
+		// Signaler les clés inconnues ou mal orthographiées avant le décodage
+		if !opts.Lenient {
+			if errs := checkKnownFields(&doc, reflect.TypeOf(cfg), ""); len(errs) > 0 {
+				for i := range errs {
+					errs[i].File = filename
+				}
+				return nil, ValidationErrors(errs)
+			}
+		}
+
+		// Un fichier vide ne contient aucun document : on garde les valeurs par défaut
+		if len(doc.Content) > 0 {
+			decoder := yaml.NewDecoder(bytes.NewReader(data))
+			decoder.KnownFields(!opts.Lenient)
+			if err := decoder.Decode(cfg); err != nil {
+				return nil, err
+			}
+		}
+
+		cfg.file = filename
+		cfg.source = &doc
 	}
 
 	return cfg, nil
@@ -107,156 +387,25 @@ func getDefaultConfig() *Config {
 		Namespace:    "teleflix",
 		StorageClass: "default",
 		Domain:       "teleflix.local",
-		Services: struct {
-			Jellyfin    ServiceConfig `yaml:"jellyfin"`
-			Sonarr      ServiceConfig `yaml:"sonarr"`
-			Radarr      ServiceConfig `yaml:"radarr"`
-			Jackett     ServiceConfig `yaml:"jackett"`
-			QBittorrent ServiceConfig `yaml:"qbittorrent"`
-		}{
-			Jellyfin: ServiceConfig{
-				Enabled: true,
-				Exposed: true, // Jellyfin exposé par défaut
-				Image:   "jellyfin/jellyfin",
-				Tag:     "latest",
-				Port:    8096,
-				Resources: ResourcesConfig{
-					Requests: struct {
-						CPU    string `yaml:"cpu"`
-						Memory string `yaml:"memory"`
-					}{CPU: "500m", Memory: "512Mi"},
-					Limits: struct {
-						CPU    string `yaml:"cpu"`
-						Memory string `yaml:"memory"`
-					}{CPU: "2", Memory: "2Gi"},
-				},
-				Volumes: []VolumeConfig{
-					{Name: "media", MountPath: "/media", ReadOnly: true},
-					{Name: "config", MountPath: "/config", Size: "1Gi"},
-				},
-			},
-			Sonarr: ServiceConfig{
-				Enabled: true,
-				Exposed: false, // Sonarr non exposé par défaut (sensible)
-				Image:   "linuxserver/sonarr",
-				Tag:     "latest",
-				Port:    8989,
-				Resources: ResourcesConfig{
-					Requests: struct {
-						CPU    string `yaml:"cpu"`
-						Memory string `yaml:"memory"`
-					}{CPU: "100m", Memory: "256Mi"},
-					Limits: struct {
-						CPU    string `yaml:"cpu"`
-						Memory string `yaml:"memory"`
-					}{CPU: "500m", Memory: "512Mi"},
-				},
-				Environment: map[string]string{
-					"PUID": "1000",
-					"PGID": "1000",
-					"TZ":   "Europe/Paris",
-				},
-				Volumes: []VolumeConfig{
-					{Name: "config", MountPath: "/config", Size: "1Gi"},
-					{Name: "downloads", MountPath: "/downloads"},
-					{Name: "media", MountPath: "/tv"},
-				},
-			},
-			Radarr: ServiceConfig{
-				Enabled: true,
-				Exposed: false, // Radarr non exposé par défaut (sensible)
-				Image:   "linuxserver/radarr",
-				Tag:     "latest",
-				Port:    7878,
-				Resources: ResourcesConfig{
-					Requests: struct {
-						CPU    string `yaml:"cpu"`
-						Memory string `yaml:"memory"`
-					}{CPU: "100m", Memory: "256Mi"},
-					Limits: struct {
-						CPU    string `yaml:"cpu"`
-						Memory string `yaml:"memory"`
-					}{CPU: "500m", Memory: "512Mi"},
-				},
-				Environment: map[string]string{
-					"PUID": "1000",
-					"PGID": "1000",
-					"TZ":   "Europe/Paris",
-				},
-				Volumes: []VolumeConfig{
-					{Name: "config", MountPath: "/config", Size: "1Gi"},
-					{Name: "downloads", MountPath: "/downloads"},
-					{Name: "media", MountPath: "/movies"},
-				},
-			},
-			Jackett: ServiceConfig{
-				Enabled: true,
-				Exposed: false, // Jackett non exposé par défaut (très sensible)
-				Image:   "linuxserver/jackett",
-				Tag:     "latest",
-				Port:    9117,
-				Resources: ResourcesConfig{
-					Requests: struct {
-						CPU    string `yaml:"cpu"`
-						Memory string `yaml:"memory"`
-					}{CPU: "100m", Memory: "128Mi"},
-					Limits: struct {
-						CPU    string `yaml:"cpu"`
-						Memory string `yaml:"memory"`
-					}{CPU: "200m", Memory: "256Mi"},
-				},
-				Environment: map[string]string{
-					"PUID": "1000",
-					"PGID": "1000",
-					"TZ":   "Europe/Paris",
-				},
-				Volumes: []VolumeConfig{
-					{Name: "config", MountPath: "/config", Size: "500Mi"},
-				},
-			},
-			QBittorrent: ServiceConfig{
-				Enabled: true,
-				Exposed: false, // qBittorrent non exposé par défaut (risque sécurité)
-				Image:   "linuxserver/qbittorrent",
-				Tag:     "latest",
-				Port:    8080,
-				Resources: ResourcesConfig{
-					Requests: struct {
-						CPU    string `yaml:"cpu"`
-						Memory string `yaml:"memory"`
-					}{CPU: "200m", Memory: "512Mi"},
-					Limits: struct {
-						CPU    string `yaml:"cpu"`
-						Memory string `yaml:"memory"`
-					}{CPU: "1", Memory: "1Gi"},
-				},
-				Environment: map[string]string{
-					"PUID":       "1000",
-					"PGID":       "1000",
-					"TZ":         "Europe/Paris",
-					"WEBUI_PORT": "8080",
-				},
-				Volumes: []VolumeConfig{
-					{Name: "config", MountPath: "/config", Size: "1Gi"},
-					{Name: "downloads", MountPath: "/downloads"},
-				},
-			},
-		},
+		Services:     defaultServiceCatalog(),
 		Storage: StorageConfig{
-			Media: struct {
-				Size        string   `yaml:"size"`
-				AccessModes []string `yaml:"accessModes"`
-			}{
+			Media: SharedVolumeConfig{
 				Size:        "100Gi",
 				AccessModes: []string{"ReadWriteMany"},
 			},
-			Downloads: struct {
-				Size        string   `yaml:"size"`
-				AccessModes []string `yaml:"accessModes"`
-			}{
+			Downloads: SharedVolumeConfig{
 				Size:        "50Gi",
 				AccessModes: []string{"ReadWriteOnce"},
 			},
+			Data: SharedVolumeConfig{
+				Size:        "150Gi",
+				AccessModes: []string{"ReadWriteMany"}, // Monté par plusieurs pods
+			},
+		},
+		PodSecurity: PodSecurityConfig{
+			Enforce: "baseline",   // Refuse les pods privilégiés
+			Audit:   "restricted", // Les pods générés respectent restricted par défaut
+			Warn:    "restricted",
 		},
 		Ingress: IngressConfig{
 			Enabled:   true,
@@ -284,5 +433,24 @@ func getDefaultConfig() *Config {
 				Email: "",            // À remplir par l'utilisateur
 			},
 		},
+		Compose: defaultComposeConfig(),
+	}
+}
+
+func defaultServiceCatalog() ServiceCatalog {
+	catalog := make(ServiceCatalog)
+	for _, name := range defaultServices {
+		catalog[name], _ = Preset(name)
+	}
+	return catalog
+}
+
+func defaultComposeConfig() ComposeConfig {
+	c := ComposeConfig{
+		Restart:      "unless-stopped",
+		PublishPorts: true,
 	}
+	c.Traefik.EntryPoint = "websecure"
+	c.Traefik.Network = "traefik"
+	return c
 }
@@ -0,0 +1,444 @@
+package config
+
+import (
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ServiceCatalog associe un nom de service à sa configuration. Les entrées
+// dont le nom (ou le champ preset) correspond à un preset intégré sont
+// fusionnées avec celui-ci : seuls les champs présents dans le YAML
+// remplacent les valeurs du preset.
+type ServiceCatalog map[string]ServiceConfig
+
+// presetOrder fixe l'ordre de génération des presets ; les services
+// personnalisés viennent ensuite, triés par nom.
+var presetOrder = []string{
+	"jellyfin", "sonarr", "radarr", "jackett", "qbittorrent",
+	"prowlarr", "bazarr", "lidarr", "readarr", "flaresolverr",
+	"jellyseerr", "overseerr",
+}
+
+// defaultServices sont les presets activés sans configuration explicite ;
+// les autres presets sont activés dès qu'ils sont déclarés dans services.
+var defaultServices = []string{"jellyfin", "sonarr", "radarr", "jackett", "qbittorrent"}
+
+// presets construit une nouvelle copie de chaque preset à chaque appel, afin
+// que le décodage YAML ne modifie jamais les valeurs partagées.
+var presets = map[string]func() ServiceConfig{
+	"jellyfin": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:   true,
+			Exposed:   true, // Jellyfin exposé par défaut
+			Image:     "jellyfin/jellyfin",
+			Tag:       "latest",
+			Port:      8096,
+			Resources: resources("500m", "512Mi", "2", "2Gi"),
+			Volumes: []VolumeConfig{
+				{Name: "media", MountPath: "/media", ReadOnly: true},
+				{Name: "config", MountPath: "/config", Size: "1Gi"},
+			},
+			Probes:         httpProbes("/health"),
+			InternetAccess: true,
+			Security:       runAs(1000, 1000), // Image officielle sans PUID/PGID
+		}
+	},
+	"sonarr": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:     true,
+			Exposed:     false, // Sonarr non exposé par défaut (sensible)
+			Image:       "linuxserver/sonarr",
+			Tag:         "latest",
+			Port:        8989,
+			Resources:   resources("100m", "256Mi", "500m", "512Mi"),
+			Environment: linuxserverEnv(),
+			Volumes: []VolumeConfig{
+				{Name: "config", MountPath: "/config", Size: "1Gi"},
+				{Name: "downloads", MountPath: "/downloads"},
+				{Name: "media", MountPath: "/tv"},
+			},
+			Probes:         httpProbes("/ping"),
+			InternetAccess: true,
+			Links:          flows("jackett", "prowlarr", "qbittorrent"),
+		}
+	},
+	"radarr": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:     true,
+			Exposed:     false, // Radarr non exposé par défaut (sensible)
+			Image:       "linuxserver/radarr",
+			Tag:         "latest",
+			Port:        7878,
+			Resources:   resources("100m", "256Mi", "500m", "512Mi"),
+			Environment: linuxserverEnv(),
+			Volumes: []VolumeConfig{
+				{Name: "config", MountPath: "/config", Size: "1Gi"},
+				{Name: "downloads", MountPath: "/downloads"},
+				{Name: "media", MountPath: "/movies"},
+			},
+			Probes:         httpProbes("/ping"),
+			InternetAccess: true,
+			Links:          flows("jackett", "prowlarr", "qbittorrent"),
+		}
+	},
+	"jackett": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:     true,
+			Exposed:     false, // Jackett non exposé par défaut (très sensible)
+			Image:       "linuxserver/jackett",
+			Tag:         "latest",
+			Port:        9117,
+			Resources:   resources("100m", "128Mi", "200m", "256Mi"),
+			Environment: linuxserverEnv(),
+			Volumes: []VolumeConfig{
+				{Name: "config", MountPath: "/config", Size: "500Mi"},
+			},
+			Probes:         tcpProbes(),
+			InternetAccess: true,
+			Links:          flows("flaresolverr"),
+		}
+	},
+	"qbittorrent": func() ServiceConfig {
+		env := linuxserverEnv()
+		env["WEBUI_PORT"] = "8080"
+		return ServiceConfig{
+			Enabled:     true,
+			Exposed:     false, // qBittorrent non exposé par défaut (risque sécurité)
+			Image:       "linuxserver/qbittorrent",
+			Tag:         "latest",
+			Port:        8080,
+			Resources:   resources("200m", "512Mi", "1", "1Gi"),
+			Environment: env,
+			// Port pair joignable depuis Internet, reporté dans la
+			// configuration de qBittorrent via TORRENTING_PORT
+			Ports: []PortConfig{
+				{Name: "torrent", Port: 6881, Protocol: "TCP", ServiceType: "LoadBalancer", Env: "TORRENTING_PORT"},
+				{Name: "torrent-udp", Port: 6881, Protocol: "UDP", ServiceType: "LoadBalancer"},
+			},
+			Volumes: []VolumeConfig{
+				{Name: "config", MountPath: "/config", Size: "1Gi"},
+				{Name: "downloads", MountPath: "/downloads"},
+			},
+			Probes:         tcpProbes(), // Port de la WebUI
+			InternetAccess: true,
+		}
+	},
+	"prowlarr": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:     true,
+			Exposed:     false, // Gestionnaire d'indexeurs, sensible comme Jackett
+			Image:       "linuxserver/prowlarr",
+			Tag:         "latest",
+			Port:        9696,
+			Resources:   resources("100m", "128Mi", "500m", "512Mi"),
+			Environment: linuxserverEnv(),
+			Volumes: []VolumeConfig{
+				{Name: "config", MountPath: "/config", Size: "500Mi"},
+			},
+			Probes:         httpProbes("/ping"),
+			InternetAccess: true,
+			Links:          flows("sonarr", "radarr", "lidarr", "readarr", "flaresolverr"),
+		}
+	},
+	"bazarr": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:     true,
+			Exposed:     false,
+			Image:       "linuxserver/bazarr",
+			Tag:         "latest",
+			Port:        6767,
+			Resources:   resources("100m", "256Mi", "500m", "512Mi"),
+			Environment: linuxserverEnv(),
+			Volumes: []VolumeConfig{
+				{Name: "config", MountPath: "/config", Size: "500Mi"},
+				{Name: "media", MountPath: "/media"},
+			},
+			Probes:         tcpProbes(),
+			InternetAccess: true,
+			Links:          flows("sonarr", "radarr"),
+		}
+	},
+	"lidarr": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:     true,
+			Exposed:     false,
+			Image:       "linuxserver/lidarr",
+			Tag:         "latest",
+			Port:        8686,
+			Resources:   resources("100m", "256Mi", "500m", "512Mi"),
+			Environment: linuxserverEnv(),
+			Volumes: []VolumeConfig{
+				{Name: "config", MountPath: "/config", Size: "1Gi"},
+				{Name: "downloads", MountPath: "/downloads"},
+				{Name: "media", MountPath: "/music"},
+			},
+			Probes:         httpProbes("/ping"),
+			InternetAccess: true,
+			Links:          flows("jackett", "prowlarr", "qbittorrent"),
+		}
+	},
+	"readarr": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:     true,
+			Exposed:     false,
+			Image:       "linuxserver/readarr",
+			Tag:         "develop", // Readarr ne publie pas de tag latest
+			Port:        8787,
+			Resources:   resources("100m", "256Mi", "500m", "512Mi"),
+			Environment: linuxserverEnv(),
+			Volumes: []VolumeConfig{
+				{Name: "config", MountPath: "/config", Size: "1Gi"},
+				{Name: "downloads", MountPath: "/downloads"},
+				{Name: "media", MountPath: "/books"},
+			},
+			Probes:         httpProbes("/ping"),
+			InternetAccess: true,
+			Links:          flows("jackett", "prowlarr", "qbittorrent"),
+		}
+	},
+	"flaresolverr": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:   true,
+			Exposed:   false, // Utilisé uniquement par Prowlarr/Jackett en interne
+			Image:     "ghcr.io/flaresolverr/flaresolverr",
+			Tag:       "latest",
+			Port:      8191,
+			Resources: resources("100m", "256Mi", "1", "1Gi"), // Chromium embarqué
+			Environment: map[string]string{
+				"LOG_LEVEL": "info",
+				"TZ":        "Europe/Paris",
+			},
+			Probes:         httpProbes("/health"),
+			InternetAccess: true,
+			Security:       runAs(1000, 1000), // Utilisateur flaresolverr de l'image
+		}
+	},
+	"jellyseerr": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:   true,
+			Exposed:   true, // Point d'entrée des demandes pour la famille
+			Image:     "fallenbagel/jellyseerr",
+			Tag:       "latest",
+			Port:      5055,
+			Resources: resources("100m", "256Mi", "500m", "512Mi"),
+			Environment: map[string]string{
+				"LOG_LEVEL": "info",
+				"TZ":        "Europe/Paris",
+			},
+			Volumes: []VolumeConfig{
+				{Name: "config", MountPath: "/app/config", Size: "1Gi"},
+			},
+			Links:          flows("jellyfin", "sonarr", "radarr"),
+			Seed:           requestsSeed(jellyfinSettings),
+			Probes:         httpProbes("/api/v1/status"),
+			InternetAccess: true,
+			Security:       runAs(1000, 1000),
+		}
+	},
+	"overseerr": func() ServiceConfig {
+		return ServiceConfig{
+			Enabled:     true,
+			Exposed:     true, // Point d'entrée des demandes (variante Plex)
+			Image:       "linuxserver/overseerr",
+			Tag:         "latest",
+			Port:        5055,
+			Resources:   resources("100m", "256Mi", "500m", "512Mi"),
+			Environment: linuxserverEnv(),
+			Volumes: []VolumeConfig{
+				{Name: "config", MountPath: "/config", Size: "1Gi"},
+			},
+			Links:          flows("sonarr", "radarr"),
+			Seed:           requestsSeed(""),
+			Probes:         httpProbes("/api/v1/status"),
+			InternetAccess: true,
+		}
+	},
+}
+
+// Preset retourne une copie du preset intégré portant ce nom.
+func Preset(name string) (ServiceConfig, bool) {
+	build, ok := presets[name]
+	if !ok {
+		return ServiceConfig{}, false
+	}
+	return build(), true
+}
+
+// PresetNames retourne les noms des presets intégrés, dans l'ordre de génération.
+func PresetNames() []string {
+	return append([]string(nil), presetOrder...)
+}
+
+// ServiceNames retourne les noms des services du catalogue dans un ordre
+// stable : les presets d'abord, puis les services personnalisés triés.
+func (c *Config) ServiceNames() []string {
+	var names []string
+	for _, name := range presetOrder {
+		if _, ok := c.Services[name]; ok {
+			names = append(names, name)
+		}
+	}
+
+	var custom []string
+	for name := range c.Services {
+		if _, ok := presets[name]; !ok {
+			custom = append(custom, name)
+		}
+	}
+	sort.Strings(custom)
+
+	return append(names, custom...)
+}
+
+// UnmarshalYAML fusionne chaque entrée du YAML avec la configuration déjà
+// présente pour ce service, ou à défaut avec le preset correspondant.
+func (s *ServiceCatalog) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		var raw map[string]ServiceConfig
+		return node.Decode(&raw) // Laisse yaml produire l'erreur de type
+	}
+	if *s == nil {
+		*s = make(ServiceCatalog)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, value := node.Content[i].Value, node.Content[i+1]
+
+		base, exists := (*s)[name]
+		if preset := mappingValue(value, "preset"); preset != nil {
+			// Un preset explicite repart de zéro à partir de ce preset
+			base, exists = Preset(preset.Value)
+		}
+		if !exists {
+			base, exists = Preset(name)
+		}
+		if !exists {
+			// Service personnalisé : déclaré donc activé, sauf mention contraire
+			base = ServiceConfig{Enabled: true, Tag: "latest"}
+		}
+
+		if err := value.Decode(&base); err != nil {
+			return err
+		}
+		(*s)[name] = base
+	}
+	return nil
+}
+
+func resources(requestCPU, requestMemory, limitCPU, limitMemory string) ResourcesConfig {
+	var r ResourcesConfig
+	r.Requests.CPU, r.Requests.Memory = requestCPU, requestMemory
+	r.Limits.CPU, r.Limits.Memory = limitCPU, limitMemory
+	return r
+}
+
+// httpProbes vérifie la santé d'un service par une requête HTTP sur path.
+func httpProbes(path string) ProbesConfig {
+	return ProbesConfig{Type: "http", Path: path}
+}
+
+// tcpProbes vérifie seulement que le port du service accepte les connexions,
+// pour les applications sans point de santé HTTP anonyme.
+func tcpProbes() ProbesConfig {
+	return ProbesConfig{Type: "tcp"}
+}
+
+// flows déclare les services avec lesquels un preset échange (indexeurs,
+// client torrent...) sans injecter d'URL. Ils alimentent les NetworkPolicies
+// et sont ignorés si le service est absent du catalogue.
+func flows(services ...string) []LinkConfig {
+	links := make([]LinkConfig, 0, len(services))
+	for _, name := range services {
+		links = append(links, LinkConfig{Service: name, Optional: true})
+	}
+	return links
+}
+
+// jellyfinSettings pré-remplit le serveur Jellyfin de Jellyseerr.
+const jellyfinSettings = `
+{{- if enabled "jellyfin" }}
+  "jellyfin": {
+    "ip": "{{ host "jellyfin" }}",
+    "port": {{ port "jellyfin" }},
+    "useSsl": false,
+    "urlBase": ""
+  },
+{{- end }}`
+
+// arrSettings déclare Sonarr et Radarr comme serveurs par défaut. La clé
+// d'API, le profil et le dossier racine restent à saisir dans l'interface.
+const arrSettings = `
+  "sonarr": [
+{{- if enabled "sonarr" }}
+    {
+      "id": 0,
+      "name": "Sonarr",
+      "hostname": "{{ host "sonarr" }}",
+      "port": {{ port "sonarr" }},
+      "apiKey": "",
+      "useSsl": false,
+      "baseUrl": "",
+      "activeProfileId": 0,
+      "activeProfileName": "",
+      "activeDirectory": "",
+      "activeAnimeProfileId": null,
+      "activeAnimeDirectory": null,
+      "tags": [],
+      "animeTags": [],
+      "is4k": false,
+      "isDefault": true,
+      "enableSeasonFolders": true,
+      "externalUrl": "",
+      "syncEnabled": false,
+      "preventSearch": false
+    }
+{{- end }}
+  ],
+  "radarr": [
+{{- if enabled "radarr" }}
+    {
+      "id": 0,
+      "name": "Radarr",
+      "hostname": "{{ host "radarr" }}",
+      "port": {{ port "radarr" }},
+      "apiKey": "",
+      "useSsl": false,
+      "baseUrl": "",
+      "activeProfileId": 0,
+      "activeProfileName": "",
+      "activeDirectory": "",
+      "minimumAvailability": "released",
+      "tags": [],
+      "is4k": false,
+      "isDefault": true,
+      "externalUrl": "",
+      "syncEnabled": false,
+      "preventSearch": false
+    }
+{{- end }}
+  ]`
+
+// requestsSeed écrit le settings.json initial de Jellyseerr ou Overseerr,
+// fusionné par l'application avec ses valeurs par défaut au démarrage.
+func requestsSeed(server string) *SeedConfig {
+	return &SeedConfig{
+		Volume:  "config",
+		File:    "settings.json",
+		Content: "{" + server + arrSettings + "\n}\n",
+	}
+}
+
+// runAs fixe l'utilisateur des images qui ne lisent pas PUID/PGID.
+func runAs(uid, gid int64) SecurityConfig {
+	return SecurityConfig{RunAsUser: &uid, RunAsGroup: &gid}
+}
+
+// linuxserverEnv retourne les variables communes aux images linuxserver.io.
+func linuxserverEnv() map[string]string {
+	return map[string]string{
+		"PUID": "1000",
+		"PGID": "1000",
+		"TZ":   "Europe/Paris",
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestServiceCatalogMergesWithPresets(t *testing.T) {
+	cfg, err := Load(filepath.Join("testdata", "catalog.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	jellyfin := cfg.Services["jellyfin"]
+	if jellyfin.Tag != "10.9.0" || jellyfin.Image != "jellyfin/jellyfin" || jellyfin.Port != 8096 {
+		t.Errorf("jellyfin devrait conserver le preset hors tag: %+v", jellyfin)
+	}
+	if jellyfin.Environment["JELLYFIN_CACHE_DIR"] != "/cache" {
+		t.Errorf("environnement jellyfin = %v", jellyfin.Environment)
+	}
+
+	fourK := cfg.Services["jellyfin-4k"]
+	if fourK.Image != "jellyfin/jellyfin" || fourK.Port != 8097 || fourK.Exposed || !fourK.Enabled {
+		t.Errorf("jellyfin-4k devrait dériver du preset jellyfin: %+v", fourK)
+	}
+
+	if cfg.Services["sonarr"].Enabled {
+		t.Error("sonarr devrait être désactivé")
+	}
+
+	homepage := cfg.Services["homepage"]
+	if !homepage.Enabled || homepage.Tag != "latest" || homepage.Port != 3000 {
+		t.Errorf("service personnalisé inattendu: %+v", homepage)
+	}
+
+	want := []string{"jellyfin", "sonarr", "radarr", "jackett", "qbittorrent", "homepage", "jellyfin-4k"}
+	if got := cfg.ServiceNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceNames() = %v, attendu %v", got, want)
+	}
+}
+
+func TestPresetsAreNotShared(t *testing.T) {
+	if _, err := Load(filepath.Join("testdata", "catalog.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	jellyfin, _ := Preset("jellyfin")
+	if jellyfin.Tag != "latest" || len(jellyfin.Environment) != 0 {
+		t.Errorf("le chargement a modifié le preset jellyfin: %+v", jellyfin)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// quantityRegexp reconnaît la syntaxe des quantités Kubernetes
+// (ex: "500m", "1.5", "512Mi", "1e3", "2G").
+var quantityRegexp = regexp.MustCompile(`^([+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+))(?:([eE][+-]?[0-9]+)|(Ki|Mi|Gi|Ti|Pi|Ei|n|u|m|k|M|G|T|P|E))?$`)
+
+var quantitySuffixes = map[string]float64{
+	"":   1,
+	"n":  1e-9,
+	"u":  1e-6,
+	"m":  1e-3,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// ParseQuantity convertit une quantité Kubernetes en valeur numérique.
+// La précision d'un float64 suffit pour comparer des requests et des limits.
+func ParseQuantity(s string) (float64, error) {
+	m := quantityRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("quantité invalide %q (attendu: 500m, 1.5, 512Mi, 10Gi...)", s)
+	}
+
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("quantité invalide %q: %w", s, err)
+	}
+
+	if m[2] != "" {
+		exp, err := strconv.Atoi(m[2][1:])
+		if err != nil {
+			return 0, fmt.Errorf("exposant invalide dans %q: %w", s, err)
+		}
+		return value * math.Pow10(exp), nil
+	}
+
+	return value * quantitySuffixes[m[3]], nil
+}
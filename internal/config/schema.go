@@ -0,0 +1,252 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaHints complète le schéma déduit des types Go avec une courte
+// description des champs et les contraintes appliquées par Validate. Dans
+// les chemins, "*" désigne une entrée de map et "[]" un élément de liste.
+// Les sections suivent l'ordre des champs de Config.
+func schemaHints() map[string]map[string]any {
+	port := func(description string) map[string]any {
+		return map[string]any{"description": description, "minimum": 1, "maximum": 65535}
+	}
+	nodePort := func(description string) map[string]any {
+		return map[string]any{"description": description, "minimum": 30000, "maximum": 32767}
+	}
+	quantity := func(description string) map[string]any {
+		return map[string]any{"description": description, "pattern": quantityRegexp.String()}
+	}
+	enum := func(description string, values []string) map[string]any {
+		return map[string]any{"description": description, "enum": values}
+	}
+	pattern := func(description string, re *regexp.Regexp) map[string]any {
+		return map[string]any{"description": description, "pattern": re.String()}
+	}
+	envNames := func(description string) map[string]any {
+		return map[string]any{"description": description, "propertyNames": map[string]any{"pattern": envVarNameRegexp.String()}}
+	}
+	accessMode := enum("Mode d'accès du PVC", validAccessModes)
+
+	return map[string]map[string]any{
+		"namespace": pattern("Namespace Kubernetes de la stack", dns1123LabelRegexp),
+		"domain":    pattern("Domaine des hôtes de l'ingress", dns1123SubdomainRegexp),
+
+		// Services
+		"services":                                       {"description": "Catalogue des services, indexé par nom", "propertyNames": map[string]any{"pattern": dns1123LabelRegexp.String()}},
+		"services.*.preset":                              enum("Preset intégré servant de base", presetOrder),
+		"services.*.port":                                port("Port principal (interface web)"),
+		"services.*.ports[].name":                        {"description": "Nom du port", "pattern": dns1123LabelRegexp.String(), "maxLength": 15},
+		"services.*.ports[].port":                        port("Numéro du port"),
+		"services.*.ports[].protocol":                    enum("Protocole du port", validProtocols),
+		"services.*.ports[].serviceType":                 enum("Service publiant le port hors du cluster", validPortTypes),
+		"services.*.ports[].nodePort":                    nodePort("Port fixe réservé sur les nœuds"),
+		"services.*.ports[].env":                         pattern("Variable recevant le numéro du port", envVarNameRegexp),
+		"services.*.replicas":                            {"description": "Nombre de pods", "minimum": 0},
+		"services.*.strategy":                            enum("Stratégie de mise à jour du Deployment", validStrategies),
+		"services.*.resources.requests.cpu":              quantity("CPU réservé"),
+		"services.*.resources.requests.memory":           quantity("Mémoire réservée"),
+		"services.*.resources.limits.cpu":                quantity("CPU maximal"),
+		"services.*.resources.limits.memory":             quantity("Mémoire maximale"),
+		"services.*.environment":                         envNames("Variables d'environnement du conteneur"),
+		"services.*.volumes[].name":                      pattern("Nom du volume", dns1123LabelRegexp),
+		"services.*.volumes[].size":                      quantity("Taille du PVC"),
+		"services.*.volumes[].accessModes[]":             accessMode,
+		"services.*.volumes[].volumeMode":                enum("Volume monté (Filesystem) ou brut (Block)", validVolumeModes),
+		"services.*.seed":                                {"description": "Fichier de configuration écrit au premier démarrage"},
+		"services.*.service.type":                        enum("Type du Service principal", validSvcTypes),
+		"services.*.service.nodePort":                    nodePort("Port fixe réservé sur les nœuds"),
+		"services.*.service.externalTrafficPolicy":       enum("Routage du trafic externe", validTrafficPols),
+		"services.*.probes.type":                         enum("Vérification de santé", validProbeTypes),
+		"services.*.probes.port":                         port("Port vérifié (défaut : port principal)"),
+		"services.*.securityContext.runAsUser":           {"description": "UID du conteneur", "minimum": 0},
+		"services.*.securityContext.runAsGroup":          {"description": "GID du conteneur", "minimum": 0},
+		"services.*.securityContext.fsGroup":             {"description": "Groupe propriétaire des volumes", "minimum": 0},
+		"services.*.securityContext.seccompProfile":      enum("Profil seccomp", validSeccomp),
+		"services.*.securityContext.capabilities.add[]":  pattern("Capability ajoutée", capabilityRegexp),
+		"services.*.securityContext.capabilities.drop[]": pattern("Capability retirée", capabilityRegexp),
+		"services.*.vpn.type":                            enum("Protocole du tunnel", validVPNTypes),
+		"services.*.vpn.forwardedPort":                   port("Port redirigé fixe du fournisseur"),
+		"services.*.vpn.outboundSubnets[]":               {"description": "Plage CIDR joignable hors du tunnel"},
+		"services.*.vpn.hostTun":                         {"description": "Monte /dev/net/tun de l'hôte"},
+		"services.*.vpn.environment":                     envNames("Variables gluetun supplémentaires"),
+
+		// Stockage
+		"storage.layout":                  enum("Disposition des volumes partagés", validLayouts),
+		"storage.media.size":              quantity("Taille du PVC media"),
+		"storage.media.accessModes[]":     accessMode,
+		"storage.downloads.size":          quantity("Taille du PVC downloads"),
+		"storage.downloads.accessModes[]": accessMode,
+		"storage.data.size":               quantity("Taille du PVC data"),
+		"storage.data.accessModes[]":      accessMode,
+
+		// Pod Security Standards
+		"podSecurity.enforce": enum("Niveau refusant les pods non conformes", validPSSLevels),
+		"podSecurity.audit":   enum("Niveau journalisé par l'audit", validPSSLevels),
+		"podSecurity.warn":    enum("Niveau signalé à l'application", validPSSLevels),
+		"podSecurity.version": pattern("Version des règles (latest, v1.30...)", pssVersionRegexp),
+
+		// NetworkPolicy
+		"networkPolicy.ingressNamespace": pattern("Namespace du contrôleur d'ingress", dns1123LabelRegexp),
+		"networkPolicy.dnsNamespace":     pattern("Namespace du DNS du cluster (défaut : kube-system)", dns1123LabelRegexp),
+		"networkPolicy.dnsSelector":      {"description": "Labels des pods DNS (défaut : k8s-app: kube-dns)"},
+
+		// cert-manager et docker-compose
+		"certManager.issuer.type": enum("Type d'émetteur", validIssuerTypes),
+		"compose.restart":         enum("Politique de redémarrage", validRestarts),
+	}
+}
+
+// JSONSchema décrit le format du fichier de configuration (JSON Schema
+// 2020-12), avec les valeurs par défaut de getDefaultConfig. Il permet
+// l'autocomplétion dans les éditeurs compatibles yaml-language-server.
+func JSONSchema() ([]byte, error) {
+	defaults := reflect.ValueOf(getDefaultConfig()).Elem()
+	schema, err := schemaFor(defaults.Type(), defaults, "", schemaHints())
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Configuration teleflix"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// schemaFor construit le schéma du type t. def porte la valeur par défaut
+// correspondante ; elle est invalide sous une map ou une liste.
+func schemaFor(t reflect.Type, def reflect.Value, fieldPath string, hints map[string]map[string]any) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if def.IsValid() {
+			if def.IsNil() {
+				def = reflect.Value{}
+			} else {
+				def = def.Elem()
+			}
+		}
+	}
+
+	schema := make(map[string]any)
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]any)
+		if err := structProperties(t, def, fieldPath, hints, properties); err != nil {
+			return nil, err
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false // Mode strict : clés inconnues refusées
+
+	case reflect.Map:
+		items, err := schemaFor(t.Elem(), reflect.Value{}, fieldPath+".*", hints)
+		if err != nil {
+			return nil, err
+		}
+		schema["type"] = "object"
+		schema["additionalProperties"] = items
+		if def.IsValid() && def.Len() > 0 {
+			if schema["default"], err = jsonValue(def.Interface()); err != nil {
+				return nil, err
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		items, err := schemaFor(t.Elem(), reflect.Value{}, fieldPath+"[]", hints)
+		if err != nil {
+			return nil, err
+		}
+		schema["type"] = "array"
+		schema["items"] = items
+		if def.IsValid() && def.Len() > 0 {
+			if schema["default"], err = jsonValue(def.Interface()); err != nil {
+				return nil, err
+			}
+		}
+
+	case reflect.String:
+		schema["type"] = "string"
+		if def.IsValid() && def.String() != "" {
+			schema["default"] = def.String()
+		}
+
+	case reflect.Bool:
+		schema["type"] = "boolean"
+		if def.IsValid() {
+			schema["default"] = def.Bool()
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema["type"] = "integer"
+		if def.IsValid() && def.Int() != 0 {
+			schema["default"] = def.Int()
+		}
+	}
+
+	for k, v := range hints[fieldPath] {
+		schema[k] = v
+	}
+	return schema, nil
+}
+
+// structProperties ajoute les champs de t à properties, en suivant les
+// mêmes règles de nommage que yamlFields.
+func structProperties(t reflect.Type, def reflect.Value, fieldPath string, hints map[string]map[string]any, properties map[string]any) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+
+		var fieldDef reflect.Value
+		if def.IsValid() {
+			fieldDef = def.Field(i)
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if strings.Contains(opts, "inline") {
+			if err := structProperties(f.Type, fieldDef, fieldPath, hints, properties); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		schema, err := schemaFor(f.Type, fieldDef, joinPath(fieldPath, name), hints)
+		if err != nil {
+			return err
+		}
+		properties[name] = schema
+	}
+	return nil
+}
+
+// jsonValue convertit une valeur Go en sa représentation YAML générique,
+// afin que les valeurs par défaut utilisent les noms de champs YAML.
+func jsonValue(v any) (any, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// checkSchemaCovers vérifie que chaque clé de value est décrite par schema.
+func checkSchemaCovers(t *testing.T, schema map[string]any, value any, fieldPath string) {
+	t.Helper()
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		items, _ := schema["additionalProperties"].(map[string]any)
+		for key, child := range v {
+			childSchema, ok := properties[key].(map[string]any)
+			if !ok {
+				childSchema = items
+			}
+			if childSchema == nil {
+				t.Errorf("%s: absent du schéma", joinPath(fieldPath, key))
+				continue
+			}
+			checkSchemaCovers(t, childSchema, child, joinPath(fieldPath, key))
+		}
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		for i, child := range v {
+			checkSchemaCovers(t, items, child, fmt.Sprintf("%s[%d]", fieldPath, i))
+		}
+	}
+}
+
+func TestJSONSchemaCoversDefaultConfig(t *testing.T) {
+	data, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schéma JSON invalide: %v", err)
+	}
+
+	defaults, err := jsonValue(getDefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSchemaCovers(t, schema, defaults, "")
+
+	properties := schema["properties"].(map[string]any)
+	if got := properties["namespace"].(map[string]any)["default"]; got != "teleflix" {
+		t.Errorf("namespace.default = %v, attendu teleflix", got)
+	}
+
+	issuer := properties["certManager"].(map[string]any)["properties"].(map[string]any)["issuer"]
+	issuerType := issuer.(map[string]any)["properties"].(map[string]any)["type"].(map[string]any)
+	if got := fmt.Sprint(issuerType["enum"]); got != fmt.Sprint(validIssuerTypes) {
+		t.Errorf("certManager.issuer.type.enum = %v, attendu %v", got, validIssuerTypes)
+	}
+
+	service := properties["services"].(map[string]any)["additionalProperties"].(map[string]any)
+	port := service["properties"].(map[string]any)["port"].(map[string]any)
+	if !reflect.DeepEqual(port["maximum"], float64(65535)) {
+		t.Errorf("services.*.port.maximum = %v, attendu 65535", port["maximum"])
+	}
+}
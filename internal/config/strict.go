@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// checkKnownFields parcourt l'arbre YAML en parallèle du type Go cible et
+// signale chaque clé qui ne correspond à aucun tag yaml, avec une suggestion
+// lorsque la clé ressemble à un champ existant.
+func checkKnownFields(node *yaml.Node, t reflect.Type, fieldPath string) []ValidationError {
+	if node == nil {
+		return nil
+	}
+	for node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		} else if len(node.Content) > 0 {
+			node = node.Content[0]
+		} else {
+			return nil
+		}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var errs []ValidationError
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+
+			field, known := fields[key.Value]
+			if !known {
+				errs = append(errs, unknownField(key, joinPath(fieldPath, key.Value), fields))
+				continue
+			}
+			errs = append(errs, checkKnownFields(value, field, joinPath(fieldPath, key.Value))...)
+		}
+
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			errs = append(errs, checkKnownFields(value, t.Elem(), joinPath(fieldPath, key.Value))...)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			errs = append(errs, checkKnownFields(item, t.Elem(), fmt.Sprintf("%s[%d]", fieldPath, i))...)
+		}
+	}
+	return errs
+}
+
+// yamlFields retourne les clés YAML acceptées par une structure, y compris
+// celles des structures intégrées avec ",inline".
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if strings.Contains(opts, "inline") {
+			for k, v := range yamlFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func unknownField(key *yaml.Node, fieldPath string, fields map[string]reflect.Type) ValidationError {
+	msg := fmt.Sprintf("champ inconnu %q", key.Value)
+	if suggestion := closestField(key.Value, fields); suggestion != "" {
+		msg += fmt.Sprintf(" (vouliez-vous dire %q ?)", suggestion)
+	}
+	return ValidationError{
+		Path:    fieldPath,
+		Line:    key.Line,
+		Column:  key.Column,
+		Message: msg,
+	}
+}
+
+// closestField retourne le champ connu le plus proche de name, ou "" si
+// aucun n'est suffisamment ressemblant.
+func closestField(name string, fields map[string]reflect.Type) string {
+	candidates := make([]string, 0, len(fields))
+	for f := range fields {
+		candidates = append(candidates, f)
+	}
+	sort.Strings(candidates)
+	return closest(name, candidates)
+}
+
+// closest retourne le candidat le plus proche de name (distance de
+// Levenshtein, sans tenir compte de la casse), ou "" s'il est trop éloigné.
+func closest(name string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		d := levenshtein(strings.ToLower(name), strings.ToLower(candidate))
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+
+	// Tolérer environ une faute de frappe tous les trois caractères
+	if bestDistance < 0 || bestDistance > max(2, len(name)/3) {
+		return ""
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	_, err := Load(filepath.Join("testdata", "typo.yaml"))
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("ValidationErrors attendu, obtenu %v", err)
+	}
+
+	want := []struct {
+		path    string
+		line    int
+		message string
+	}{
+		{"storageclass", 1, `champ inconnu "storageclass" (vouliez-vous dire "storageClass" ?)`},
+		{"services.jellyfin.exposd", 4, `champ inconnu "exposd" (vouliez-vous dire "exposed" ?)`},
+		{"services.sonarr.enabld", 6, `champ inconnu "enabld" (vouliez-vous dire "enabled" ?)`},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("%d erreurs attendues, obtenu %d:\n%v", len(want), len(errs), err)
+	}
+	for i, w := range want {
+		if errs[i].Path != w.path || errs[i].Line != w.line || errs[i].Message != w.message {
+			t.Errorf("erreur %d = %d %s: %s, attendu %d %s: %s",
+				i, errs[i].Line, errs[i].Path, errs[i].Message, w.line, w.path, w.message)
+		}
+	}
+}
+
+func TestLoadLenientIgnoresUnknownFields(t *testing.T) {
+	cfg, err := LoadWithOptions(filepath.Join("testdata", "typo.yaml"), LoadOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("le mode lenient ne devrait pas échouer: %v", err)
+	}
+	if cfg.StorageClass != "default" {
+		t.Errorf("storageClass = %q, la valeur par défaut devrait être conservée", cfg.StorageClass)
+	}
+}
+
+func TestClosestField(t *testing.T) {
+	fields := yamlFields(reflect.TypeOf(ServiceConfig{}))
+
+	tests := map[string]string{
+		"exposd":     "exposed",
+		"Enabled":    "enabled",
+		"envronment": "environment",
+		"xyz":        "",
+	}
+	for in, want := range tests {
+		if got := closestField(in, fields); got != want {
+			t.Errorf("closestField(%q) = %q, attendu %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,872 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Valeurs acceptées pour les champs énumérés
+var (
+	validAccessModes = []string{"ReadWriteOnce", "ReadOnlyMany", "ReadWriteMany", "ReadWriteOncePod"}
+	validIssuerTypes = []string{"letsencrypt", "selfsigned"}
+	validRestarts    = []string{"no", "always", "on-failure", "unless-stopped"}
+	validProbeTypes  = []string{"http", "tcp", "none"}
+	validSeccomp     = []string{"RuntimeDefault", "Unconfined"}
+	validPSSLevels   = []string{"privileged", "baseline", "restricted"}
+	validVPNTypes    = []string{"wireguard", "openvpn"}
+	validProtocols   = []string{"TCP", "UDP"}
+	validPortTypes   = []string{"NodePort", "LoadBalancer"}
+	validSvcTypes    = []string{"ClusterIP", "NodePort", "LoadBalancer"}
+	validTrafficPols = []string{"Cluster", "Local"}
+	validLayouts     = []string{"split", "unified"}
+	validVolumeModes = []string{"Filesystem", "Block"}
+	validStrategies  = []string{"Recreate", "RollingUpdate"}
+)
+
+// sharedVolumes sont les volumes adossés aux PVC communs générés par generatePVCs.
+var sharedVolumes = []string{"media", "downloads"}
+
+var (
+	dns1123LabelRegexp     = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	dns1123SubdomainRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	envVarNameRegexp       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	pssVersionRegexp       = regexp.MustCompile(`^(latest|v1\.[0-9]+)$`)
+	capabilityRegexp       = regexp.MustCompile(`^[A-Z][A-Z_]*$`)
+	labelValueRegexp       = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?)?$`)
+)
+
+// ValidationError décrit un problème de configuration localisé dans le fichier YAML.
+type ValidationError struct {
+	File    string
+	Path    string // Chemin du champ, ex: services.jellyfin.volumes[0].size
+	Line    int    // 0 si la valeur ne provient pas du fichier
+	Column  int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	var location string
+	switch {
+	case e.File != "" && e.Line > 0:
+		location = fmt.Sprintf("%s:%d:%d: ", e.File, e.Line, e.Column)
+	case e.File != "":
+		location = e.File + ": "
+	}
+	return fmt.Sprintf("%s%s: %s", location, e.Path, e.Message)
+}
+
+// ValidationErrors regroupe tous les problèmes détectés en une seule passe.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	if len(e) == 1 {
+		lines = append(lines, "configuration invalide (1 erreur):")
+	} else {
+		lines = append(lines, fmt.Sprintf("configuration invalide (%d erreurs):", len(e)))
+	}
+	for _, err := range e {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate vérifie l'ensemble de la configuration et retourne toutes les
+// erreurs trouvées sous forme de ValidationErrors, ou nil si elle est valide.
+func (c *Config) Validate() error {
+	v := &validator{cfg: c}
+
+	v.dnsLabel("namespace", c.Namespace)
+	v.dnsSubdomain("domain", c.Domain)
+	if c.StorageClass != "" {
+		v.dnsSubdomain("storageClass", c.StorageClass)
+	}
+
+	v.validateServices()
+	v.validateStorage()
+	v.validatePodSecurity()
+	v.validateNetworkPolicy()
+	v.validateIngress()
+	v.validateCertManager()
+	v.validateCompose()
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+type validator struct {
+	cfg  *Config
+	errs ValidationErrors
+}
+
+// addf enregistre une erreur pour le champ donné, avec sa position dans le fichier.
+func (v *validator) addf(fieldPath, format string, args ...any) {
+	line, column := v.cfg.position(fieldPath)
+	v.errs = append(v.errs, ValidationError{
+		File:    v.cfg.file,
+		Path:    fieldPath,
+		Line:    line,
+		Column:  column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) dnsLabel(fieldPath, value string) {
+	switch {
+	case value == "":
+		v.addf(fieldPath, "valeur requise")
+	case len(value) > 63 || !dns1123LabelRegexp.MatchString(value):
+		v.addf(fieldPath, "%q n'est pas un nom RFC 1123 valide (minuscules, chiffres et '-', 63 caractères max)", value)
+	}
+}
+
+func (v *validator) dnsSubdomain(fieldPath, value string) {
+	switch {
+	case value == "":
+		v.addf(fieldPath, "valeur requise")
+	case len(value) > 253 || !dns1123SubdomainRegexp.MatchString(value):
+		v.addf(fieldPath, "%q n'est pas un nom de domaine RFC 1123 valide", value)
+	default:
+		for _, label := range strings.Split(value, ".") {
+			if len(label) > 63 {
+				v.addf(fieldPath, "le label %q dépasse 63 caractères", label)
+			}
+		}
+	}
+}
+
+// quantity vérifie la syntaxe d'une quantité et retourne sa valeur si elle est valide.
+func (v *validator) quantity(fieldPath, value string) (float64, bool) {
+	q, err := ParseQuantity(value)
+	if err != nil {
+		v.addf(fieldPath, "%v", err)
+		return 0, false
+	}
+	if q < 0 {
+		v.addf(fieldPath, "la quantité %q doit être positive", value)
+		return 0, false
+	}
+	return q, true
+}
+
+func (v *validator) nonNegative(fieldPath string, value int32) {
+	if value < 0 {
+		v.addf(fieldPath, "la valeur ne peut pas être négative (obtenu: %d)", value)
+	}
+}
+
+func (v *validator) oneOf(fieldPath, value string, allowed []string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.addf(fieldPath, "valeur %q non supportée (valeurs possibles: %s)", value, strings.Join(allowed, ", "))
+}
+
+func (v *validator) accessModes(fieldPath string, modes []string) {
+	if len(modes) == 0 {
+		v.addf(fieldPath, "au moins un mode d'accès est requis")
+	}
+	for i, mode := range modes {
+		v.oneOf(fmt.Sprintf("%s[%d]", fieldPath, i), mode, validAccessModes)
+	}
+}
+
+func (v *validator) validateServices() {
+	// Un nodePort est réservé sur tous les nœuds du cluster
+	nodePorts := make(map[int32]string)
+	for _, name := range v.cfg.ServiceNames() {
+		svc := v.cfg.Services[name]
+		prefix := "services." + name
+
+		if svc.Preset != "" {
+			if _, ok := presets[svc.Preset]; !ok {
+				v.addf(prefix+".preset", "preset %q inconnu (presets disponibles: %s)",
+					svc.Preset, strings.Join(presetOrder, ", "))
+			}
+		}
+		if !svc.Enabled {
+			continue
+		}
+
+		// Le nom du service sert de nom aux objets Kubernetes et de sous-domaine
+		v.dnsLabel(prefix, name)
+
+		if svc.Image == "" {
+			// Un nom de preset mal orthographié crée un service personnalisé vide
+			if suggestion := closest(name, presetOrder); svc.Preset == "" && suggestion != "" {
+				v.addf(prefix+".image", "image requise pour le service personnalisé %q (vouliez-vous dire le preset %q ?)", name, suggestion)
+			} else {
+				v.addf(prefix+".image", "image requise pour un service activé")
+			}
+		}
+		if svc.Tag == "" {
+			v.addf(prefix+".tag", "tag requis pour un service activé")
+		}
+
+		if svc.Port < 1 || svc.Port > 65535 {
+			v.addf(prefix+".port", "le port doit être compris entre 1 et 65535 (obtenu: %d)", svc.Port)
+		}
+		if svc.Replicas != nil && *svc.Replicas < 0 {
+			v.addf(prefix+".replicas", "le nombre de réplicas ne peut pas être négatif (obtenu: %d)", *svc.Replicas)
+		}
+		if svc.Strategy != "" {
+			v.oneOf(prefix+".strategy", svc.Strategy, validStrategies)
+		}
+
+		v.validateKubeService(prefix+".service", name, svc.Service, nodePorts)
+		v.validatePorts(prefix+".ports", name, svc, nodePorts)
+		v.validateResources(prefix+".resources", svc.Resources)
+		v.validateVolumes(prefix+".volumes", svc.Volumes)
+		v.validateLinks(prefix+".links", name, svc.Links)
+		if svc.Seed != nil {
+			v.validateSeed(prefix+".seed", svc)
+		}
+		v.validateProbes(prefix+".probes", svc.Probes)
+		v.validateSecurity(prefix, svc)
+		v.validateVPN(prefix, svc)
+	}
+}
+
+// validateSecurity reçoit le préfixe du service, car le volume réservé
+// tmp se trouve hors du bloc securityContext.
+func (v *validator) validateSecurity(svcPrefix string, svc ServiceConfig) {
+	sec := svc.Security
+	prefix := svcPrefix + ".securityContext"
+	for _, id := range []struct {
+		name  string
+		value *int64
+	}{
+		{"runAsUser", sec.RunAsUser},
+		{"runAsGroup", sec.RunAsGroup},
+		{"fsGroup", sec.FSGroup},
+	} {
+		if id.value != nil && *id.value < 0 {
+			v.addf(prefix+"."+id.name, "l'identifiant ne peut pas être négatif (obtenu: %d)", *id.value)
+		}
+	}
+	if sec.SeccompProfile != "" {
+		v.oneOf(prefix+".seccompProfile", sec.SeccompProfile, validSeccomp)
+	}
+
+	for _, caps := range []struct {
+		name   string
+		values []string
+	}{
+		{"add", sec.Capabilities.Add},
+		{"drop", sec.Capabilities.Drop},
+	} {
+		for i, c := range caps.values {
+			if !capabilityRegexp.MatchString(c) || strings.HasPrefix(c, "CAP_") {
+				v.addf(fmt.Sprintf("%s.capabilities.%s[%d]", prefix, caps.name, i), "capability invalide %q (ex: NET_BIND_SERVICE, sans préfixe CAP_)", c)
+			}
+		}
+	}
+
+	// Le volume tmp est réservé au /tmp inscriptible d'une racine en lecture seule
+	if sec.ReadOnlyRootFilesystem {
+		for i, vol := range svc.Volumes {
+			if vol.Name == "tmp" {
+				v.addf(fmt.Sprintf("%s.volumes[%d].name", svcPrefix, i), "nom réservé avec readOnlyRootFilesystem")
+			}
+		}
+	}
+}
+
+func (v *validator) validateProbes(prefix string, probes ProbesConfig) {
+	if probes.Type == "" {
+		return
+	}
+	v.oneOf(prefix+".type", probes.Type, validProbeTypes)
+	if probes.Type == "http" && !strings.HasPrefix(probes.Path, "/") {
+		v.addf(prefix+".path", "chemin absolu requis pour une sonde http (obtenu: %q)", probes.Path)
+	}
+	if probes.Port != 0 && (probes.Port < 1 || probes.Port > 65535) {
+		v.addf(prefix+".port", "le port doit être compris entre 1 et 65535 (obtenu: %d)", probes.Port)
+	}
+
+	for _, t := range []struct {
+		name   string
+		timing ProbeTiming
+	}{
+		{"liveness", probes.Liveness},
+		{"readiness", probes.Readiness},
+		{"startup", probes.Startup},
+	} {
+		timingPath := prefix + "." + t.name
+		v.nonNegative(timingPath+".initialDelaySeconds", t.timing.InitialDelaySeconds)
+		v.nonNegative(timingPath+".periodSeconds", t.timing.PeriodSeconds)
+		v.nonNegative(timingPath+".timeoutSeconds", t.timing.TimeoutSeconds)
+		v.nonNegative(timingPath+".failureThreshold", t.timing.FailureThreshold)
+	}
+}
+
+// MainPortName nomme le port principal lorsque le Service en compte
+// plusieurs ; il est donc réservé.
+const MainPortName = "http"
+
+func (v *validator) validateKubeService(prefix, name string, svc KubeServiceConfig, nodePorts map[int32]string) {
+	if svc.Type != "" {
+		v.oneOf(prefix+".type", svc.Type, validSvcTypes)
+	}
+	external := svc.Type == "NodePort" || svc.Type == "LoadBalancer"
+
+	if svc.NodePort != 0 {
+		if !external {
+			v.addf(prefix+".nodePort", "requiert le type NodePort ou LoadBalancer")
+		}
+		v.nodePort(prefix+".nodePort", name, svc.NodePort, nodePorts)
+	}
+	if svc.LoadBalancerIP != "" {
+		if svc.Type != "LoadBalancer" {
+			v.addf(prefix+".loadBalancerIP", "requiert le type LoadBalancer")
+		}
+		if net.ParseIP(svc.LoadBalancerIP) == nil {
+			v.addf(prefix+".loadBalancerIP", "%q n'est pas une adresse IP valide", svc.LoadBalancerIP)
+		}
+	}
+	if svc.ExternalTrafficPolicy != "" {
+		if !external {
+			v.addf(prefix+".externalTrafficPolicy", "requiert le type NodePort ou LoadBalancer")
+		}
+		v.oneOf(prefix+".externalTrafficPolicy", svc.ExternalTrafficPolicy, validTrafficPols)
+	}
+}
+
+// nodePort vérifie la plage du port et son unicité dans le cluster.
+func (v *validator) nodePort(fieldPath, name string, port int32, nodePorts map[int32]string) {
+	if port < 30000 || port > 32767 {
+		v.addf(fieldPath, "le nodePort doit être compris entre 30000 et 32767 (obtenu: %d)", port)
+	} else if other, exists := nodePorts[port]; exists {
+		v.addf(fieldPath, "le nodePort %d est déjà utilisé par le service %s", port, other)
+	} else {
+		nodePorts[port] = name
+	}
+}
+
+func (v *validator) validatePorts(prefix, name string, svc ServiceConfig, nodePorts map[int32]string) {
+	names := map[string]bool{MainPortName: true}
+	bindings := map[string]bool{fmt.Sprintf("%d/TCP", svc.Port): true}
+	for i, p := range svc.Ports {
+		portPath := fmt.Sprintf("%s[%d]", prefix, i)
+
+		switch {
+		case len(p.Name) > 15:
+			v.addf(portPath+".name", "%q dépasse 15 caractères", p.Name)
+		case names[p.Name]:
+			v.addf(portPath+".name", "nom de port %q déjà utilisé", p.Name)
+		default:
+			v.dnsLabel(portPath+".name", p.Name)
+		}
+		names[p.Name] = true
+
+		if p.Port < 1 || p.Port > 65535 {
+			v.addf(portPath+".port", "le port doit être compris entre 1 et 65535 (obtenu: %d)", p.Port)
+		}
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		v.oneOf(portPath+".protocol", protocol, validProtocols)
+		if binding := fmt.Sprintf("%d/%s", p.Port, protocol); bindings[binding] {
+			v.addf(portPath+".port", "port %s déjà déclaré par le service", binding)
+		} else {
+			bindings[binding] = true
+		}
+
+		if p.ServiceType != "" {
+			v.oneOf(portPath+".serviceType", p.ServiceType, validPortTypes)
+		}
+		if p.NodePort != 0 {
+			if p.ServiceType == "" {
+				v.addf(portPath+".nodePort", "requiert serviceType NodePort ou LoadBalancer")
+			}
+			v.nodePort(portPath+".nodePort", name, p.NodePort, nodePorts)
+		}
+		if p.Env != "" && !envVarNameRegexp.MatchString(p.Env) {
+			v.addf(portPath+".env", "%q n'est pas un nom de variable d'environnement valide", p.Env)
+		}
+	}
+}
+
+// validateVPN reçoit le préfixe du service, car le volume réservé tun se
+// trouve hors du bloc vpn.
+func (v *validator) validateVPN(svcPrefix string, svc ServiceConfig) {
+	vpn := svc.VPN
+	if !vpn.Enabled {
+		return
+	}
+	prefix := svcPrefix + ".vpn"
+
+	// NET_ADMIN n'est admis par aucun niveau des Pod Security Standards
+	// hormis privileged : le pod serait refusé par l'admission
+	if enforce := v.cfg.PodSecurity.Enforce; enforce != "" && enforce != "privileged" {
+		v.addf(prefix+".enabled", "le sidecar VPN requiert NET_ADMIN, refusé par podSecurity.enforce: %s (utiliser privileged)", enforce)
+	}
+	if vpn.Provider == "" {
+		v.addf(prefix+".provider", "fournisseur VPN requis (ex: mullvad, protonvpn, custom)")
+	}
+	if vpn.Type != "" {
+		v.oneOf(prefix+".type", vpn.Type, validVPNTypes)
+	}
+	if vpn.SecretName != "" {
+		v.dnsSubdomain(prefix+".secretName", vpn.SecretName)
+	}
+	if vpn.ForwardedPort != 0 && (vpn.ForwardedPort < 1 || vpn.ForwardedPort > 65535) {
+		v.addf(prefix+".forwardedPort", "le port doit être compris entre 1 et 65535 (obtenu: %d)", vpn.ForwardedPort)
+	}
+	if vpn.ForwardedPort != 0 && vpn.PortForwarding {
+		v.addf(prefix+".portForwarding", "incompatible avec forwardedPort (port fixe ou dynamique, pas les deux)")
+	}
+	if vpn.ForwardedPort != 0 {
+		v.validateForwardedPort(prefix+".forwardedPort", svc)
+	}
+	for i, subnet := range vpn.OutboundSubnets {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			v.addf(fmt.Sprintf("%s.outboundSubnets[%d]", prefix, i), "plage CIDR invalide %q (ex: 10.42.0.0/16)", subnet)
+		}
+	}
+
+	for i, vol := range svc.Volumes {
+		if vol.Name == "tun" {
+			v.addf(fmt.Sprintf("%s.volumes[%d].name", svcPrefix, i), "nom réservé au périphérique du VPN")
+		}
+	}
+}
+
+// validateForwardedPort vérifie que le port redirigé peut remplacer les
+// ports publiés du service (serviceType), ex: le port torrent TCP et UDP.
+func (v *validator) validateForwardedPort(fieldPath string, svc ServiceConfig) {
+	if svc.VPN.ForwardedPort == svc.Port {
+		v.addf(fieldPath, "le port %d est déjà utilisé par le port principal", svc.Port)
+	}
+	var published []string
+	for _, p := range svc.Ports {
+		if p.ServiceType == "" {
+			continue
+		}
+		if port := strconv.Itoa(int(p.Port)); !slices.Contains(published, port) {
+			published = append(published, port)
+		}
+	}
+	switch {
+	case len(published) == 0:
+		v.addf(fieldPath, "aucun port publié (serviceType) à remplacer par le port redirigé")
+	case len(published) > 1:
+		v.addf(fieldPath, "les ports publiés doivent partager un même numéro pour être remplacés par le port redirigé (obtenu: %s)", strings.Join(published, ", "))
+	}
+}
+
+func (v *validator) validateLinks(prefix, name string, links []LinkConfig) {
+	for i, link := range links {
+		linkPath := fmt.Sprintf("%s[%d]", prefix, i)
+
+		switch _, exists := v.cfg.Services[link.Service]; {
+		case link.Service == "":
+			v.addf(linkPath+".service", "service cible requis")
+		case link.Service == name:
+			v.addf(linkPath+".service", "un service ne peut pas dépendre de lui-même")
+		case !exists && !link.Optional:
+			v.addf(linkPath+".service", "service %q absent du catalogue", link.Service)
+		}
+
+		if link.Env != "" && !envVarNameRegexp.MatchString(link.Env) {
+			v.addf(linkPath+".env", "%q n'est pas un nom de variable d'environnement valide", link.Env)
+		}
+	}
+}
+
+func (v *validator) validateSeed(prefix string, svc ServiceConfig) {
+	seed := svc.Seed
+	if !slices.ContainsFunc(svc.Volumes, func(vol VolumeConfig) bool { return vol.Name == seed.Volume }) {
+		v.addf(prefix+".volume", "volume %q absent des volumes du service", seed.Volume)
+	}
+	for i, vol := range svc.Volumes {
+		if vol.Name == "seed" {
+			v.addf(fmt.Sprintf("%s[%d].name", strings.TrimSuffix(prefix, ".seed")+".volumes", i), "nom réservé au fichier initial (seed)")
+		}
+	}
+	if seed.File == "" || path.IsAbs(seed.File) || path.Clean(seed.File) != seed.File || strings.HasPrefix(seed.File, "..") {
+		v.addf(prefix+".file", "chemin relatif au volume requis (obtenu: %q)", seed.File)
+	}
+
+	// Les fonctions vérifient que les services désignés existent ; enabled
+	// suit leur présence afin d'évaluer chaque branche
+	service := func(name string) (ServiceConfig, error) {
+		target, ok := v.cfg.Services[name]
+		if !ok {
+			return ServiceConfig{}, fmt.Errorf("service %q absent du catalogue", name)
+		}
+		return target, nil
+	}
+	funcs := template.FuncMap{
+		"enabled": func(name string) bool { _, ok := v.cfg.Services[name]; return ok },
+		"host":    func(name string) (string, error) { _, err := service(name); return name, err },
+		"port":    func(name string) (int32, error) { target, err := service(name); return target.Port, err },
+		"url":     func(name string) (string, error) { _, err := service(name); return name, err },
+	}
+	tmpl, err := template.New(seed.File).Funcs(funcs).Parse(seed.Content)
+	if err == nil {
+		err = tmpl.Execute(io.Discard, nil)
+	}
+	if err != nil {
+		v.addf(prefix+".content", "template invalide: %v", err)
+	}
+}
+
+func (v *validator) validateResources(prefix string, res ResourcesConfig) {
+	resources := []struct {
+		name           string
+		request, limit string
+	}{
+		{"cpu", res.Requests.CPU, res.Limits.CPU},
+		{"memory", res.Requests.Memory, res.Limits.Memory},
+	}
+
+	for _, r := range resources {
+		var request, limit float64
+		var requestOK, limitOK bool
+		if r.request != "" {
+			request, requestOK = v.quantity(prefix+".requests."+r.name, r.request)
+		}
+		if r.limit != "" {
+			limit, limitOK = v.quantity(prefix+".limits."+r.name, r.limit)
+		}
+		if requestOK && limitOK && request > limit {
+			v.addf(prefix+".requests."+r.name, "la request %s (%s) dépasse la limit (%s)", r.name, r.request, r.limit)
+		}
+	}
+}
+
+func (v *validator) validateVolumes(prefix string, volumes []VolumeConfig) {
+	unified := v.cfg.Storage.Layout == "unified"
+	names := make(map[string]bool)
+	mountPaths := make(map[string]bool)
+
+	for i, vol := range volumes {
+		volPath := fmt.Sprintf("%s[%d]", prefix, i)
+
+		v.dnsLabel(volPath+".name", vol.Name)
+		if unified && vol.Name == "data" {
+			v.addf(volPath+".name", "le nom data est réservé au volume partagé (storage.layout: unified)")
+		} else if names[vol.Name] {
+			v.addf(volPath+".name", "volume %q déclaré plusieurs fois", vol.Name)
+		}
+		names[vol.Name] = true
+
+		switch {
+		case vol.MountPath == "":
+			v.addf(volPath+".mountPath", "point de montage requis")
+		case !path.IsAbs(vol.MountPath):
+			v.addf(volPath+".mountPath", "le point de montage %q doit être un chemin absolu", vol.MountPath)
+		case mountPaths[path.Clean(vol.MountPath)]:
+			v.addf(volPath+".mountPath", "point de montage %q utilisé plusieurs fois", vol.MountPath)
+		case unified && !isSharedVolume(vol.Name) && pathWithin(vol.MountPath, UnifiedDataPath):
+			v.addf(volPath+".mountPath", "%s est réservé au volume data (storage.layout: unified)", UnifiedDataPath)
+		}
+		mountPaths[path.Clean(vol.MountPath)] = true
+
+		switch {
+		case vol.Size != "":
+			v.quantity(volPath+".size", vol.Size)
+		case isSharedVolume(vol.Name) || vol.ExistingClaim != "" || vol.Inline():
+		default:
+			v.addf(volPath+".size", "taille requise pour le volume %q (seuls %s utilisent un PVC partagé)",
+				vol.Name, strings.Join(sharedVolumes, " et "))
+		}
+
+		if isSharedVolume(vol.Name) {
+			if option := claimOption(vol); option != "" {
+				v.addf(volPath+"."+option, "se définit dans storage.%s pour le volume partagé %s", vol.Name, vol.Name)
+			} else if vol.VolumeMode != "" {
+				v.addf(volPath+".volumeMode", "non pris en charge pour le volume partagé %s", vol.Name)
+			} else if len(vol.Sources()) > 0 {
+				v.addf(volPath+"."+vol.Sources()[0], "la source du volume partagé %s se définit dans storage.%s", vol.Name, vol.Name)
+			}
+			continue
+		}
+		v.validateVolumeSource(volPath, vol.VolumeSourceConfig)
+		v.validateClaimOptions(volPath, vol)
+	}
+}
+
+// claimOption retourne la première option du PVC généré définie sur le
+// volume. volumeMode n'en fait pas partie : il décide aussi du montage d'un
+// PVC existant.
+func claimOption(vol VolumeConfig) string {
+	switch {
+	case vol.StorageClass != "":
+		return "storageClass"
+	case len(vol.AccessModes) > 0:
+		return "accessModes"
+	case len(vol.Annotations) > 0:
+		return "annotations"
+	}
+	return ""
+}
+
+func (v *validator) validateClaimOptions(prefix string, vol VolumeConfig) {
+	if vol.VolumeMode != "" {
+		v.oneOf(prefix+".volumeMode", vol.VolumeMode, validVolumeModes)
+		if vol.Inline() {
+			v.addf(prefix+".volumeMode", "requiert un PVC")
+		}
+	}
+	if vol.Inline() || vol.ExistingClaim != "" {
+		if option := claimOption(vol); option != "" {
+			v.addf(prefix+"."+option, "sans effet : aucun PVC n'est généré pour ce volume")
+		}
+		return
+	}
+
+	if vol.StorageClass != "" {
+		v.dnsSubdomain(prefix+".storageClass", vol.StorageClass)
+	}
+	if len(vol.AccessModes) > 0 {
+		v.accessModes(prefix+".accessModes", vol.AccessModes)
+	}
+}
+
+func (v *validator) validateVolumeSource(prefix string, src VolumeSourceConfig) {
+	sources := src.Sources()
+	if len(sources) > 1 {
+		v.addf(prefix+"."+sources[1], "une seule source par volume (déjà défini: %s)", sources[0])
+		return
+	}
+
+	switch {
+	case src.ExistingClaim != "":
+		v.dnsSubdomain(prefix+".existingClaim", src.ExistingClaim)
+	case src.HostPath != "" && !path.IsAbs(src.HostPath):
+		v.addf(prefix+".hostPath", "le chemin %q doit être absolu", src.HostPath)
+	case src.NFS != nil:
+		if src.NFS.Server == "" {
+			v.addf(prefix+".nfs.server", "serveur NFS requis")
+		}
+		if !path.IsAbs(src.NFS.Path) {
+			v.addf(prefix+".nfs.path", "le chemin exporté %q doit être absolu", src.NFS.Path)
+		}
+	case src.SMB != nil:
+		if !strings.HasPrefix(src.SMB.Source, "//") {
+			v.addf(prefix+".smb.source", "partage invalide %q (attendu: //serveur/partage)", src.SMB.Source)
+		}
+		v.dnsSubdomain(prefix+".smb.secretName", src.SMB.SecretName)
+	}
+
+	if src.PersistentVolume && src.HostPath == "" && src.NFS == nil && src.SMB == nil {
+		v.addf(prefix+".persistentVolume", "requiert une source hostPath, nfs ou smb")
+	}
+}
+
+// UnifiedDataPath est le point de montage du volume data : médias et
+// téléchargements y ont le même chemin dans tous les services.
+const UnifiedDataPath = "/data"
+
+func pathWithin(p, dir string) bool {
+	p = path.Clean(p)
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+func isSharedVolume(name string) bool {
+	for _, shared := range sharedVolumes {
+		if name == shared {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) validateStorage() {
+	storage := v.cfg.Storage
+	if storage.Layout == "unified" {
+		v.validateSharedVolume("storage.data", storage.Data)
+		return
+	}
+	if storage.Layout != "" {
+		v.oneOf("storage.layout", storage.Layout, validLayouts)
+	}
+	v.validateSharedVolume("storage.media", storage.Media)
+	v.validateSharedVolume("storage.downloads", storage.Downloads)
+}
+
+func (v *validator) validateSharedVolume(prefix string, vol SharedVolumeConfig) {
+	if vol.ExistingClaim == "" {
+		v.quantity(prefix+".size", vol.Size)
+		v.accessModes(prefix+".accessModes", vol.AccessModes)
+		if vol.StorageClass != "" {
+			v.dnsSubdomain(prefix+".storageClass", vol.StorageClass)
+		}
+	}
+	// Chaque pod recevrait son propre volume temporaire
+	if vol.EmptyDir {
+		v.addf(prefix+".emptyDir", "un volume partagé ne peut pas être temporaire")
+		return
+	}
+	v.validateVolumeSource(prefix, vol.VolumeSourceConfig)
+}
+
+func (v *validator) validateNetworkPolicy() {
+	np := v.cfg.NetworkPolicy
+	if !np.Enabled {
+		return
+	}
+	// Un mauvais namespace couperait silencieusement l'accès web : pas de valeur par défaut
+	if np.IngressNamespace == "" {
+		v.addf("networkPolicy.ingressNamespace", "requis avec networkPolicy.enabled (kube-system pour Traefik sur k3s, ingress-nginx pour nginx)")
+		return
+	}
+	v.dnsLabel("networkPolicy.ingressNamespace", np.IngressNamespace)
+	if np.DNSNamespace != "" {
+		v.dnsLabel("networkPolicy.dnsNamespace", np.DNSNamespace)
+	}
+	// Les clés contiennent souvent des points : l'erreur pointe sur la map
+	for _, key := range slices.Sorted(maps.Keys(np.DNSSelector)) {
+		v.label("networkPolicy.dnsSelector", key, np.DNSSelector[key])
+	}
+}
+
+// label vérifie une paire clé/valeur de label Kubernetes, ex:
+// app.kubernetes.io/name: coredns.
+func (v *validator) label(fieldPath, key, value string) {
+	name := key
+	if prefix, rest, found := strings.Cut(key, "/"); found {
+		v.dnsSubdomain(fieldPath, prefix)
+		name = rest
+	}
+	if name == "" || len(name) > 63 || !labelValueRegexp.MatchString(name) {
+		v.addf(fieldPath, "%q n'est pas une clé de label valide", key)
+	}
+	if len(value) > 63 || !labelValueRegexp.MatchString(value) {
+		v.addf(fieldPath, "%q n'est pas une valeur de label valide (63 caractères max)", value)
+	}
+}
+
+func (v *validator) validatePodSecurity() {
+	pss := v.cfg.PodSecurity
+	for _, mode := range []struct {
+		name  string
+		level string
+	}{
+		{"enforce", pss.Enforce},
+		{"audit", pss.Audit},
+		{"warn", pss.Warn},
+	} {
+		if mode.level != "" {
+			v.oneOf("podSecurity."+mode.name, mode.level, validPSSLevels)
+		}
+	}
+	if pss.Version != "" && !pssVersionRegexp.MatchString(pss.Version) {
+		v.addf("podSecurity.version", "version invalide %q (ex: latest, v1.30)", pss.Version)
+	}
+}
+
+func (v *validator) validateIngress() {
+	ing := v.cfg.Ingress
+	if !ing.Enabled {
+		return
+	}
+	if ing.ClassName != "" {
+		v.dnsSubdomain("ingress.className", ing.ClassName)
+	}
+	if ing.TLS.Enabled {
+		v.dnsSubdomain("ingress.tls.secretName", ing.TLS.SecretName)
+	}
+}
+
+func (v *validator) validateCertManager() {
+	cm := v.cfg.CertManager
+	if !cm.Enabled {
+		return
+	}
+	v.dnsSubdomain("certManager.issuer.name", cm.Issuer.Name)
+	v.oneOf("certManager.issuer.type", cm.Issuer.Type, validIssuerTypes)
+	if cm.Issuer.Type == "letsencrypt" {
+		if cm.Issuer.Email == "" {
+			v.addf("certManager.issuer.email", "email requis pour Let's Encrypt")
+		} else if !strings.Contains(cm.Issuer.Email, "@") {
+			v.addf("certManager.issuer.email", "adresse email invalide %q", cm.Issuer.Email)
+		}
+	}
+}
+
+func (v *validator) validateCompose() {
+	compose := v.cfg.Compose
+	v.oneOf("compose.restart", compose.Restart, validRestarts)
+	if compose.Traefik.Enabled && compose.Traefik.Network != "" {
+		v.dnsLabel("compose.traefik.network", compose.Traefik.Network)
+	}
+}
+
+// position retourne la ligne et la colonne du champ dans le fichier source.
+// Si le champ est absent (valeur par défaut), la position de l'ancêtre le plus
+// proche présent dans le fichier est utilisée ; (0, 0) si aucun n'existe.
+func (c *Config) position(fieldPath string) (int, int) {
+	if c.source == nil || len(c.source.Content) == 0 {
+		return 0, 0
+	}
+
+	node := c.source.Content[0]
+	line, column := 0, 0
+	for _, segment := range strings.Split(fieldPath, ".") {
+		key, indexes := splitIndexes(segment)
+
+		node = mappingValue(node, key)
+		if node == nil {
+			break
+		}
+		line, column = node.Line, node.Column
+
+		for _, index := range indexes {
+			if node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+				return line, column
+			}
+			node = node.Content[index]
+			line, column = node.Line, node.Column
+		}
+	}
+	return line, column
+}
+
+// splitIndexes sépare "volumes[1]" en ("volumes", [1]).
+func splitIndexes(segment string) (string, []int) {
+	key, rest, found := strings.Cut(segment, "[")
+	if !found {
+		return segment, nil
+	}
+
+	var indexes []int
+	for _, part := range strings.Split(strings.TrimSuffix(rest, "]"), "][") {
+		index, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		indexes = append(indexes, index)
+	}
+	return key, indexes
+}
+
+// mappingValue retourne le nœud valeur associé à key dans un mapping YAML.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
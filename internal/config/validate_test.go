@@ -0,0 +1,288 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	if err := getDefaultConfig().Validate(); err != nil {
+		t.Fatalf("la configuration par défaut devrait être valide: %v", err)
+	}
+}
+
+func TestValidateReportsAllErrorsWithPositions(t *testing.T) {
+	file := filepath.Join("testdata", "invalid.yaml")
+	cfg, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cfg.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("ValidationErrors attendu, obtenu %v", err)
+	}
+
+	want := []struct {
+		path         string
+		line, column int
+	}{
+		{"namespace", 1, 12},
+		{"services.jellyfin.port", 5, 11},
+		{"services.jellyfin.service.loadBalancerIP", 12, 23},
+		{"services.jellyfin.resources.requests.memory", 8, 17},
+		{"services.radarr.port", 14, 11},
+		{"services.radarr.ports[0].name", 21, 15},
+		{"services.radarr.probes.path", 16, 13},
+		{"services.radarr.securityContext.capabilities.add[0]", 19, 15},
+		{"storage.media.size", 25, 11},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("%d erreurs attendues, obtenu %d:\n%v", len(want), len(errs), err)
+	}
+	for i, w := range want {
+		got := errs[i]
+		if got.Path != w.path || got.Line != w.line || got.Column != w.column || got.File != file {
+			t.Errorf("erreur %d = %s:%d:%d %s, attendu %s:%d:%d %s",
+				i, got.File, got.Line, got.Column, got.Path, file, w.line, w.column, w.path)
+		}
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"500m", 0.5, true},
+		{"2", 2, true},
+		{"1.5", 1.5, true},
+		{"512Mi", 512 * 1024 * 1024, true},
+		{"1e3", 1000, true},
+		{"10G", 10e9, true},
+		{"banana", 0, false},
+		{"", 0, false},
+		{"1.5.2Gi", 0, false},
+		{"10GB", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuantity(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseQuantity(%q) erreur = %v, attendu ok=%v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && got != tt.want {
+			t.Errorf("ParseQuantity(%q) = %v, attendu %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSameContainerPortInSeveralServices(t *testing.T) {
+	cfg := getDefaultConfig()
+	for _, name := range []string{"jellyseerr", "overseerr"} {
+		svc, _ := Preset(name)
+		svc.Enabled = true
+		cfg.Services[name] = svc
+	}
+
+	// Chaque Service Kubernetes a sa propre IP : le port 5055 peut être partagé
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("jellyseerr et overseerr devraient pouvoir coexister: %v", err)
+	}
+}
+
+func TestNetworkPolicyRequiresIngressNamespace(t *testing.T) {
+	cfg := getDefaultConfig()
+	if cfg.NetworkPolicy.Enabled {
+		t.Fatal("les NetworkPolicies ne doivent pas être activées par défaut")
+	}
+
+	cfg.NetworkPolicy.Enabled = true
+	var errs ValidationErrors
+	if !errors.As(cfg.Validate(), &errs) || len(errs) != 1 || errs[0].Path != "networkPolicy.ingressNamespace" {
+		t.Fatalf("erreur attendue sur networkPolicy.ingressNamespace, obtenu %v", errs)
+	}
+
+	cfg.NetworkPolicy.IngressNamespace = "ingress-nginx"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("configuration valide attendue: %v", err)
+	}
+}
+
+func TestNetworkPolicyDNSSelector(t *testing.T) {
+	cfg := getDefaultConfig()
+	cfg.NetworkPolicy = NetworkPolicyConfig{
+		Enabled:          true,
+		IngressNamespace: "kube-system",
+		DNSNamespace:     "dns",
+		DNSSelector:      map[string]string{"app.kubernetes.io/name": "coredns"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("configuration valide attendue: %v", err)
+	}
+
+	cfg.NetworkPolicy.DNSSelector["k8s-app"] = "kube dns"
+	var errs ValidationErrors
+	if !errors.As(cfg.Validate(), &errs) || len(errs) != 1 || errs[0].Path != "networkPolicy.dnsSelector" {
+		t.Fatalf("erreur attendue sur networkPolicy.dnsSelector, obtenu %v", errs)
+	}
+}
+
+func TestUnifiedLayoutReservesDataVolume(t *testing.T) {
+	cfg := getDefaultConfig()
+	cfg.Storage.Layout = "unified"
+	sonarr := cfg.Services["sonarr"]
+	sonarr.Volumes = append(sonarr.Volumes, VolumeConfig{Name: "cache", MountPath: "/data/cache", Size: "1Gi"})
+	cfg.Services["sonarr"] = sonarr
+
+	var errs ValidationErrors
+	if !errors.As(cfg.Validate(), &errs) || len(errs) != 1 || errs[0].Path != "services.sonarr.volumes[3].mountPath" {
+		t.Fatalf("erreur attendue sur services.sonarr.volumes[3].mountPath, obtenu %v", errs)
+	}
+}
+
+func TestValidateVolumeSources(t *testing.T) {
+	tests := []struct {
+		name string
+		vol  VolumeConfig
+		path string // Chemin de l'erreur attendue, vide si valide
+	}{
+		{"pvc existant sans taille", VolumeConfig{Name: "cache", MountPath: "/cache", VolumeSourceConfig: VolumeSourceConfig{ExistingClaim: "cache"}}, ""},
+		{"emptyDir sans taille", VolumeConfig{Name: "cache", MountPath: "/cache", VolumeSourceConfig: VolumeSourceConfig{EmptyDir: true}}, ""},
+		{"pv statique sans taille", VolumeConfig{Name: "cache", MountPath: "/cache", VolumeSourceConfig: VolumeSourceConfig{HostPath: "/srv/cache", PersistentVolume: true}}, "services.sonarr.volumes[3].size"},
+		{"deux sources", VolumeConfig{Name: "cache", MountPath: "/cache", VolumeSourceConfig: VolumeSourceConfig{EmptyDir: true, HostPath: "/srv/cache"}}, "services.sonarr.volumes[3].hostPath"},
+		{"nfs sans serveur", VolumeConfig{Name: "cache", MountPath: "/cache", VolumeSourceConfig: VolumeSourceConfig{NFS: &NFSConfig{Path: "/export"}}}, "services.sonarr.volumes[3].nfs.server"},
+		{"smb sans //", VolumeConfig{Name: "cache", MountPath: "/cache", Size: "1Gi", VolumeSourceConfig: VolumeSourceConfig{SMB: &SMBConfig{Source: "nas/share", SecretName: "smb"}}}, "services.sonarr.volumes[3].smb.source"},
+		{"options du pvc", VolumeConfig{Name: "cache", MountPath: "/cache", Size: "1Gi", StorageClass: "longhorn", AccessModes: []string{"ReadWriteOncePod"}, VolumeMode: "Block"}, ""},
+		{"storageClass sans pvc", VolumeConfig{Name: "cache", MountPath: "/cache", StorageClass: "longhorn", VolumeSourceConfig: VolumeSourceConfig{EmptyDir: true}}, "services.sonarr.volumes[3].storageClass"},
+		{"storageClass d'un volume partagé", VolumeConfig{Name: "media", MountPath: "/tv", StorageClass: "bulk"}, "services.sonarr.volumes[2].storageClass"},
+		{"source d'un volume partagé", VolumeConfig{Name: "media", MountPath: "/tv", VolumeSourceConfig: VolumeSourceConfig{HostPath: "/srv/media"}}, "services.sonarr.volumes[2].hostPath"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := getDefaultConfig()
+			sonarr := cfg.Services["sonarr"]
+			// Remplace le volume du preset de même nom, sinon l'ajoute
+			sonarr.Volumes = slices.Clone(sonarr.Volumes)
+			if i := slices.IndexFunc(sonarr.Volumes, func(v VolumeConfig) bool { return v.Name == tt.vol.Name }); i >= 0 {
+				sonarr.Volumes[i] = tt.vol
+			} else {
+				sonarr.Volumes = append(sonarr.Volumes, tt.vol)
+			}
+			cfg.Services["sonarr"] = sonarr
+
+			err := cfg.Validate()
+			var errs ValidationErrors
+			switch {
+			case tt.path == "" && err != nil:
+				t.Fatalf("configuration valide attendue, obtenu %v", err)
+			case tt.path != "" && (!errors.As(err, &errs) || len(errs) != 1 || errs[0].Path != tt.path):
+				t.Fatalf("erreur attendue sur %s, obtenu %v", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestValidateVPNRequiresPrivileged(t *testing.T) {
+	cfg := getDefaultConfig()
+	qbittorrent := cfg.Services["qbittorrent"]
+	qbittorrent.VPN = VPNConfig{Enabled: true, Provider: "mullvad"}
+	cfg.Services["qbittorrent"] = qbittorrent
+
+	// enforce: baseline par défaut refuserait le pod à l'admission
+	var errs ValidationErrors
+	if !errors.As(cfg.Validate(), &errs) || len(errs) != 1 || errs[0].Path != "services.qbittorrent.vpn.enabled" {
+		t.Fatalf("erreur attendue sur services.qbittorrent.vpn.enabled, obtenu %v", errs)
+	}
+
+	cfg.PodSecurity.Enforce = "privileged"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("configuration valide attendue: %v", err)
+	}
+}
+
+func TestValidateVPNOutboundSubnets(t *testing.T) {
+	cfg := getDefaultConfig()
+	cfg.PodSecurity.Enforce = "privileged"
+	qbittorrent := cfg.Services["qbittorrent"]
+	qbittorrent.VPN = VPNConfig{Enabled: true, Provider: "mullvad", OutboundSubnets: []string{"10.244.0.0/16", "10.96.0.0"}}
+	cfg.Services["qbittorrent"] = qbittorrent
+
+	var errs ValidationErrors
+	if !errors.As(cfg.Validate(), &errs) || len(errs) != 1 || errs[0].Path != "services.qbittorrent.vpn.outboundSubnets[1]" {
+		t.Fatalf("erreur attendue sur services.qbittorrent.vpn.outboundSubnets[1], obtenu %v", errs)
+	}
+}
+
+func TestValidateForwardedPort(t *testing.T) {
+	tests := []struct {
+		name  string
+		port  int32
+		ports []PortConfig // nil : ports du preset qbittorrent
+		path  string       // Chemin de l'erreur attendue, vide si valide
+	}{
+		{"port torrent du preset", 51413, nil, ""},
+		{"port principal", 8080, nil, "services.qbittorrent.vpn.forwardedPort"},
+		{"aucun port publié", 51413, []PortConfig{{Name: "torrent", Port: 6881}}, "services.qbittorrent.vpn.forwardedPort"},
+		{"ports publiés distincts", 51413, []PortConfig{
+			{Name: "torrent", Port: 6881, ServiceType: "LoadBalancer"},
+			{Name: "dht", Port: 6882, Protocol: "UDP", ServiceType: "LoadBalancer"},
+		}, "services.qbittorrent.vpn.forwardedPort"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := getDefaultConfig()
+			cfg.PodSecurity.Enforce = "privileged"
+			qbittorrent := cfg.Services["qbittorrent"]
+			qbittorrent.VPN = VPNConfig{Enabled: true, Provider: "airvpn", ForwardedPort: tt.port}
+			if tt.ports != nil {
+				qbittorrent.Ports = tt.ports
+			}
+			cfg.Services["qbittorrent"] = qbittorrent
+
+			err := cfg.Validate()
+			var errs ValidationErrors
+			switch {
+			case tt.path == "" && err != nil:
+				t.Fatalf("configuration valide attendue, obtenu %v", err)
+			case tt.path != "" && (!errors.As(err, &errs) || len(errs) != 1 || errs[0].Path != tt.path):
+				t.Fatalf("erreur attendue sur %s, obtenu %v", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestValidateSeed(t *testing.T) {
+	tests := []struct {
+		name string
+		seed SeedConfig
+		path string // Chemin de l'erreur attendue, vide si valide
+	}{
+		{"preset", *requestsSeed(jellyfinSettings), ""},
+		{"volume inconnu", SeedConfig{Volume: "data", File: "settings.json"}, "services.jellyseerr.seed.volume"},
+		{"chemin hors du volume", SeedConfig{Volume: "config", File: "../settings.json"}, "services.jellyseerr.seed.file"},
+		{"fonction inconnue", SeedConfig{Volume: "config", File: "settings.json", Content: `{{ ip "jellyfin" }}`}, "services.jellyseerr.seed.content"},
+		{"service absent", SeedConfig{Volume: "config", File: "settings.json", Content: `{{ url "plex" }}`}, "services.jellyseerr.seed.content"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := getDefaultConfig()
+			jellyseerr, _ := Preset("jellyseerr")
+			jellyseerr.Seed = &tt.seed
+			cfg.Services["jellyseerr"] = jellyseerr
+
+			err := cfg.Validate()
+			var errs ValidationErrors
+			switch {
+			case tt.path == "" && err != nil:
+				t.Fatalf("configuration valide attendue, obtenu %v", err)
+			case tt.path != "" && (!errors.As(err, &errs) || len(errs) != 1 || errs[0].Path != tt.path):
+				t.Fatalf("erreur attendue sur %s, obtenu %v", tt.path, err)
+			}
+		})
+	}
+}
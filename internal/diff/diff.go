@@ -0,0 +1,303 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Key identifie un objet Kubernetes indépendamment du fichier qui le contient.
+type Key struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+}
+
+func (k Key) String() string {
+	name := k.Name
+	if k.Namespace != "" {
+		name = k.Namespace + "/" + k.Name
+	}
+	return fmt.Sprintf("%s %s %s", k.APIVersion, k.Kind, name)
+}
+
+// Object est un objet décodé sous forme générique.
+type Object struct {
+	Key     Key
+	Source  string // Fichier d'origine, pour l'affichage
+	Content map[string]any
+}
+
+// Decode lit un flux YAML (ou JSON) multi-documents. Les objets List sont
+// dépliés et les documents vides ignorés.
+func Decode(data []byte, source string) ([]Object, error) {
+	var objects []Object
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var content map[string]any
+		err := decoder.Decode(&content)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		if content == nil {
+			continue
+		}
+
+		if content["kind"] == "List" {
+			items, _ := content["items"].([]any)
+			for _, item := range items {
+				if m, ok := item.(map[string]any); ok {
+					objects = append(objects, newObject(m, source))
+				}
+			}
+			continue
+		}
+		objects = append(objects, newObject(content, source))
+	}
+	return objects, nil
+}
+
+func newObject(content map[string]any, source string) Object {
+	metadata, _ := content["metadata"].(map[string]any)
+	str := func(m map[string]any, key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	return Object{
+		Key: Key{
+			APIVersion: str(content, "apiVersion"),
+			Kind:       str(content, "kind"),
+			Namespace:  str(metadata, "namespace"),
+			Name:       str(metadata, "name"),
+		},
+		Source:  source,
+		Content: content,
+	}
+}
+
+// ChangeType indique si un objet a été ajouté, supprimé ou modifié.
+type ChangeType string
+
+const (
+	Added   ChangeType = "+"
+	Removed ChangeType = "-"
+	Changed ChangeType = "~"
+)
+
+// Change décrit la différence portant sur un objet.
+type Change struct {
+	Type   ChangeType
+	Key    Key
+	Source string
+	Fields []FieldChange // Renseigné pour les objets modifiés
+}
+
+// FieldChange décrit la différence portant sur un champ. Old est nil pour
+// un champ ajouté, New est nil pour un champ supprimé.
+type FieldChange struct {
+	Path string
+	Old  any
+	New  any
+}
+
+func (f FieldChange) Type() ChangeType {
+	switch {
+	case f.Old == nil:
+		return Added
+	case f.New == nil:
+		return Removed
+	default:
+		return Changed
+	}
+}
+
+// Compare rapproche les objets par apiVersion/kind/namespace/name. Les
+// objets ajoutés ou modifiés suivent l'ordre de current, les objets
+// supprimés viennent ensuite dans l'ordre de previous.
+func Compare(previous, current []Object) []Change {
+	before := make(map[Key]Object, len(previous))
+	for _, obj := range previous {
+		before[obj.Key] = obj
+	}
+
+	var changes []Change
+	seen := make(map[Key]bool, len(current))
+	for _, obj := range current {
+		seen[obj.Key] = true
+		old, exists := before[obj.Key]
+		if !exists {
+			changes = append(changes, Change{Type: Added, Key: obj.Key, Source: obj.Source})
+			continue
+		}
+		if fields := compareValues("", old.Content, obj.Content); len(fields) > 0 {
+			changes = append(changes, Change{Type: Changed, Key: obj.Key, Source: obj.Source, Fields: fields})
+		}
+	}
+	for _, obj := range previous {
+		if !seen[obj.Key] {
+			changes = append(changes, Change{Type: Removed, Key: obj.Key, Source: obj.Source})
+		}
+	}
+	return changes
+}
+
+func compareValues(fieldPath string, old, new any) []FieldChange {
+	switch o := old.(type) {
+	case map[string]any:
+		if n, ok := new.(map[string]any); ok {
+			return compareMaps(fieldPath, o, n)
+		}
+	case []any:
+		if n, ok := new.([]any); ok {
+			return compareLists(fieldPath, o, n)
+		}
+	}
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+	return []FieldChange{{Path: fieldPath, Old: old, New: new}}
+}
+
+func compareMaps(fieldPath string, old, new map[string]any) []FieldChange {
+	keys := make(map[string]bool, len(old)+len(new))
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var fields []FieldChange
+	for _, k := range sorted {
+		fields = append(fields, compareValues(joinPath(fieldPath, k), old[k], new[k])...)
+	}
+	return fields
+}
+
+// compareLists rapproche les éléments par leur champ name lorsque tous en
+// ont un (containers, env, volumes...), sinon par position.
+func compareLists(fieldPath string, old, new []any) []FieldChange {
+	oldNames, okOld := listNames(old)
+	newNames, okNew := listNames(new)
+	if !okOld || !okNew {
+		var fields []FieldChange
+		for i := 0; i < len(old) || i < len(new); i++ {
+			var o, n any
+			if i < len(old) {
+				o = old[i]
+			}
+			if i < len(new) {
+				n = new[i]
+			}
+			fields = append(fields, compareValues(fmt.Sprintf("%s[%d]", fieldPath, i), o, n)...)
+		}
+		return fields
+	}
+
+	byName := make(map[string]any, len(old))
+	for i, name := range oldNames {
+		byName[name] = old[i]
+	}
+
+	var fields []FieldChange
+	seen := make(map[string]bool, len(new))
+	for i, name := range newNames {
+		seen[name] = true
+		fields = append(fields, compareValues(fmt.Sprintf("%s[%s]", fieldPath, name), byName[name], new[i])...)
+	}
+	for i, name := range oldNames {
+		if !seen[name] {
+			fields = append(fields, FieldChange{Path: fmt.Sprintf("%s[%s]", fieldPath, name), Old: old[i]})
+		}
+	}
+	return fields
+}
+
+// listNames retourne le champ name de chaque élément, si tous en ont un
+// et qu'il est unique.
+func listNames(list []any) ([]string, bool) {
+	if len(list) == 0 {
+		return nil, true
+	}
+	names := make([]string, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		name, ok := m["name"].(string)
+		if !ok || seen[name] {
+			return nil, false
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, true
+}
+
+// Write affiche les différences, une ligne par objet puis une par champ.
+func Write(w io.Writer, changes []Change) error {
+	var b strings.Builder
+	counts := make(map[ChangeType]int)
+	for _, c := range changes {
+		counts[c.Type]++
+		fmt.Fprintf(&b, "%s %s (%s)\n", c.Type, c.Key, c.Source)
+		for _, f := range c.Fields {
+			switch f.Type() {
+			case Added:
+				fmt.Fprintf(&b, "    + %s: %s\n", f.Path, formatValue(f.New))
+			case Removed:
+				fmt.Fprintf(&b, "    - %s: %s\n", f.Path, formatValue(f.Old))
+			default:
+				fmt.Fprintf(&b, "    ~ %s: %s → %s\n", f.Path, formatValue(f.Old), formatValue(f.New))
+			}
+		}
+	}
+	fmt.Fprintf(&b, "\n%d ressource(s) modifiée(s), %d ajoutée(s), %d supprimée(s)\n",
+		counts[Changed], counts[Added], counts[Removed])
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatValue affiche une valeur sur une ligne : chaînes entre guillemets,
+// objets et listes en JSON compact.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case map[string]any, []any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
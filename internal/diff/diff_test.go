@@ -0,0 +1,127 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const previousYAML = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: sonarr
+    namespace: teleflix
+spec:
+    replicas: 1
+    template:
+        spec:
+            containers:
+                - name: sonarr
+                  image: linuxserver/sonarr:latest
+                  env:
+                    - name: TZ
+                      value: Europe/Paris
+                    - name: PUID
+                      value: "1000"
+---
+apiVersion: v1
+kind: Service
+metadata:
+    name: jackett
+    namespace: teleflix
+`
+
+// Même Deployment au format JSON (List), modifié, et un nouveau Service.
+const currentJSON = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "apiVersion": "apps/v1",
+      "kind": "Deployment",
+      "metadata": {"name": "sonarr", "namespace": "teleflix"},
+      "spec": {
+        "replicas": 2,
+        "template": {"spec": {"containers": [{
+          "name": "sonarr",
+          "image": "linuxserver/sonarr:develop",
+          "env": [
+            {"name": "PUID", "value": "1000"},
+            {"name": "TZ", "value": "UTC"}
+          ]
+        }]}}
+      }
+    },
+    {
+      "apiVersion": "v1",
+      "kind": "Service",
+      "metadata": {"name": "prowlarr", "namespace": "teleflix"}
+    }
+  ]
+}`
+
+func decode(t *testing.T, data, source string) []Object {
+	t.Helper()
+	objects, err := Decode([]byte(data), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objects
+}
+
+func TestCompare(t *testing.T) {
+	changes := Compare(decode(t, previousYAML, "old.yaml"), decode(t, currentJSON, "new.json"))
+
+	var got []string
+	for _, c := range changes {
+		got = append(got, string(c.Type)+" "+c.Key.String())
+	}
+	want := []string{
+		"~ apps/v1 Deployment teleflix/sonarr",
+		"+ v1 Service teleflix/prowlarr",
+		"- v1 Service teleflix/jackett",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("changements = %v, attendu %v", got, want)
+	}
+
+	// Les listes nommées sont rapprochées par nom, pas par position
+	var fields []string
+	for _, f := range changes[0].Fields {
+		fields = append(fields, string(f.Type())+" "+f.Path)
+	}
+	wantFields := []string{
+		"~ spec.replicas",
+		"~ spec.template.spec.containers[sonarr].env[TZ].value",
+		"~ spec.template.spec.containers[sonarr].image",
+	}
+	if !reflect.DeepEqual(fields, wantFields) {
+		t.Fatalf("champs = %v, attendu %v", fields, wantFields)
+	}
+}
+
+func TestCompareIdentical(t *testing.T) {
+	objects := decode(t, previousYAML, "old.yaml")
+	if changes := Compare(objects, decode(t, previousYAML, "copy.yaml")); len(changes) != 0 {
+		t.Fatalf("aucun changement attendu, obtenu %v", changes)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	changes := Compare(decode(t, previousYAML, "old.yaml"), decode(t, currentJSON, "new.json"))
+
+	var b strings.Builder
+	if err := Write(&b, changes); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"~ apps/v1 Deployment teleflix/sonarr (new.json)",
+		`    ~ spec.template.spec.containers[sonarr].image: "linuxserver/sonarr:latest" → "linuxserver/sonarr:develop"`,
+		"    ~ spec.replicas: 1 → 2",
+		"1 ressource(s) modifiée(s), 1 ajoutée(s), 1 supprimée(s)",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("ligne absente: %q\n%s", line, b.String())
+		}
+	}
+}
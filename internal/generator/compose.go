@@ -0,0 +1,258 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"teleflix/internal/compose"
+	"teleflix/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GenerateCompose produit un fichier docker-compose équivalent aux manifests
+// Kubernetes, pour déployer la même stack sur un hôte unique.
+func (g *Generator) GenerateCompose() (map[string]string, error) {
+	project := &compose.Project{
+		Name:     g.config.Namespace,
+		Services: make(map[string]compose.Service),
+		Volumes:  make(map[string]compose.Volume),
+	}
+
+	traefik := g.config.Compose.Traefik
+	// Contrairement aux Services Kubernetes, les ports publiés partagent l'hôte
+	hostPorts := make(map[string]string)
+	for _, svc := range g.services() {
+		if !svc.config.Enabled {
+			continue
+		}
+
+		// Mieux vaut refuser que démarrer le service hors du tunnel
+		if svc.config.VPN.Enabled {
+			return nil, fmt.Errorf("service %s: le sidecar VPN n'est pas pris en charge par la sortie compose", svc.name)
+		}
+
+		service, err := g.createComposeService(svc.name, svc.config, project.Volumes)
+		if err != nil {
+			return nil, err
+		}
+		for _, mapping := range service.Ports {
+			binding := hostBinding(mapping)
+			if other, exists := hostPorts[binding]; exists {
+				return nil, fmt.Errorf("service %s: le port %s est déjà publié sur l'hôte par le service %s", svc.name, binding, other)
+			}
+			hostPorts[binding] = svc.name
+		}
+
+		if traefik.Enabled && svc.config.Exposed {
+			service.Labels = g.traefikLabels(svc.name, svc.config)
+			if traefik.Network != "" {
+				service.Networks = []string{"default", traefik.Network}
+			}
+		}
+		project.Services[svc.name] = service
+	}
+
+	if traefik.Enabled && traefik.Network != "" {
+		project.Networks = map[string]compose.Network{
+			traefik.Network: {External: true},
+		}
+	}
+
+	data, err := yaml.Marshal(project)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"docker-compose": string(data)}, nil
+}
+
+// hostBinding extrait le port hôte et le protocole d'un mapping "hôte:conteneur[/udp]".
+func hostBinding(mapping string) string {
+	host, rest, _ := strings.Cut(mapping, ":")
+	if _, proto, ok := strings.Cut(rest, "/"); ok {
+		return host + "/" + proto
+	}
+	return host + "/tcp"
+}
+
+func (g *Generator) createComposeService(name string, cfg config.ServiceConfig, volumes map[string]compose.Volume) (compose.Service, error) {
+	// Les variables explicites restent prioritaires sur les liens
+	env := g.linkEnvironment(cfg, composeURL)
+	for key, value := range portEnvironment(cfg) {
+		env[key] = value
+	}
+	for key, value := range cfg.Environment {
+		env[key] = value
+	}
+
+	service := compose.Service{
+		Image:       fmt.Sprintf("%s:%s", cfg.Image, cfg.Tag),
+		Restart:     g.config.Compose.Restart,
+		Environment: env,
+	}
+
+	if g.config.Compose.PublishPorts {
+		service.Ports = []string{fmt.Sprintf("%d:%d", cfg.Port, cfg.Port)}
+		for _, p := range extraPorts(cfg) {
+			mapping := fmt.Sprintf("%d:%d", p.Port, p.Port)
+			if portProtocol(p) == "UDP" {
+				mapping += "/udp"
+			}
+			service.Ports = append(service.Ports, mapping)
+		}
+	}
+
+	for _, m := range g.volumeMounts(name, cfg) {
+		source, volume, err := g.composeVolumeSource(name, m)
+		if err != nil {
+			return compose.Service{}, fmt.Errorf("service %s: %w", name, err)
+		}
+		target := m.MountPath
+		if volume != nil {
+			volumes[source] = *volume
+			if m.SubPath != "" {
+				// La syntaxe courte ne sait pas monter un sous-dossier d'un
+				// volume nommé : il est monté entier, les chemins restent les mêmes
+				target = config.UnifiedDataPath
+			}
+		}
+
+		// Sans source, docker crée un volume anonyme, comme un emptyDir
+		mount := target
+		if source != "" {
+			mount = fmt.Sprintf("%s:%s", source, target)
+		}
+		if m.ReadOnly {
+			mount += ":ro"
+		}
+		service.Volumes = append(service.Volumes, mount)
+	}
+
+	deploy, err := composeResources(cfg.Resources)
+	if err != nil {
+		return compose.Service{}, fmt.Errorf("service %s: %w", name, err)
+	}
+	service.Deploy = deploy
+
+	return service, nil
+}
+
+// composeVolumeSource retourne le chemin hôte d'un bind mount, ou le nom et
+// la définition d'un volume nommé.
+func (g *Generator) composeVolumeSource(name string, m volumeMount) (string, *compose.Volume, error) {
+	shared := isSharedVolume(m.Volume) || (g.unifiedLayout() && m.Volume == "data")
+	hostPaths := map[string]string{
+		"data":      g.config.Compose.DataPath,
+		"media":     g.config.Compose.MediaPath,
+		"downloads": g.config.Compose.DownloadsPath,
+	}
+
+	volumeName := fmt.Sprintf("%s-%s", name, m.Volume)
+	if shared {
+		volumeName = m.Volume
+	}
+
+	src := m.Source
+	switch {
+	case shared && hostPaths[m.Volume] != "":
+		return path.Join(hostPaths[m.Volume], m.SubPath), nil, nil
+	case src.HostPath != "":
+		return path.Join(src.HostPath, m.SubPath), nil, nil
+	case m.Block:
+		return "", nil, fmt.Errorf("volume %s: le mode Block n'est pas pris en charge par la sortie compose", m.Volume)
+	case src.EmptyDir:
+		return "", nil, nil
+	case src.NFS != nil:
+		return volumeName, &compose.Volume{
+			Driver: "local",
+			DriverOpts: map[string]string{
+				"type":   "nfs",
+				"o":      "addr=" + src.NFS.Server,
+				"device": ":" + src.NFS.Path,
+			},
+		}, nil
+	case src.SMB != nil:
+		return "", nil, fmt.Errorf("volume %s: les partages SMB ne sont pas pris en charge par la sortie compose (utiliser un chemin hôte monté)", m.Volume)
+	default:
+		return volumeName, &compose.Volume{}, nil
+	}
+}
+
+// traefikLabels reproduit la règle d'ingress d'un service exposé.
+func (g *Generator) traefikLabels(name string, cfg config.ServiceConfig) map[string]string {
+	traefik := g.config.Compose.Traefik
+	router := "traefik.http.routers." + name
+
+	labels := map[string]string{
+		"traefik.enable":    "true",
+		router + ".rule":    fmt.Sprintf("Host(`%s.%s`)", name, g.config.Domain),
+		router + ".service": name,
+		"traefik.http.services." + name + ".loadbalancer.server.port": strconv.Itoa(int(cfg.Port)),
+	}
+	if traefik.EntryPoint != "" {
+		labels[router+".entrypoints"] = traefik.EntryPoint
+	}
+	if g.config.Ingress.TLS.Enabled {
+		labels[router+".tls"] = "true"
+		if traefik.CertResolver != "" {
+			labels[router+".tls.certresolver"] = traefik.CertResolver
+		}
+	}
+	if traefik.Network != "" {
+		labels["traefik.docker.network"] = traefik.Network
+	}
+	return labels
+}
+
+// composeURL retourne l'adresse d'un service sur le réseau compose.
+func composeURL(name string, cfg config.ServiceConfig) string {
+	return fmt.Sprintf("http://%s:%d", name, cfg.Port)
+}
+
+// composeResources convertit les quantités Kubernetes au format compose
+// (CPU décimal, mémoire en octets).
+func composeResources(res config.ResourcesConfig) (*compose.Deploy, error) {
+	limits, err := composeResourceValues(res.Limits.CPU, res.Limits.Memory)
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := composeResourceValues(res.Requests.CPU, res.Requests.Memory)
+	if err != nil {
+		return nil, err
+	}
+	if limits == nil && reservations == nil {
+		return nil, nil
+	}
+	return &compose.Deploy{
+		Resources: compose.Resources{
+			Limits:       limits,
+			Reservations: reservations,
+		},
+	}, nil
+}
+
+func composeResourceValues(cpu, memory string) (*compose.ResourceValues, error) {
+	if cpu == "" && memory == "" {
+		return nil, nil
+	}
+
+	values := &compose.ResourceValues{}
+	if cpu != "" {
+		q, err := config.ParseQuantity(cpu)
+		if err != nil {
+			return nil, err
+		}
+		values.CPUs = strconv.FormatFloat(q, 'f', -1, 64)
+	}
+	if memory != "" {
+		q, err := config.ParseQuantity(memory)
+		if err != nil {
+			return nil, err
+		}
+		values.Memory = strconv.FormatInt(int64(q), 10)
+	}
+	return values, nil
+}
@@ -2,6 +2,8 @@ package generator
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"teleflix/internal/config"
@@ -18,147 +20,171 @@ func New(cfg *config.Config) *Generator {
 	return &Generator{config: cfg}
 }
 
-func (g *Generator) GenerateAll() (map[string]string, error) {
-	manifests := make(map[string]string)
-
-	// Générer le namespace
-	ns := g.generateNamespace()
-	manifests["00-namespace"] = ns
+// Manifest regroupe les objets Kubernetes écrits dans un même fichier.
+type Manifest struct {
+	Name    string // Nom du fichier sans extension, ex: 03-jellyfin
+	Service string // Service du catalogue concerné, vide pour les ressources communes
+	Objects []any
+}
 
-	// Générer les PVC
-	pvcManifests, err := g.generatePVCs()
+// Manifests construit tous les objets Kubernetes, dans l'ordre d'application.
+func (g *Generator) Manifests() ([]Manifest, error) {
+	manifests, err := g.buildManifests()
 	if err != nil {
 		return nil, err
 	}
-	manifests["01-storage"] = pvcManifests
+	if err := g.enforcePodSecurity(manifests); err != nil {
+		return nil, err
+	}
+	return manifests, nil
+}
+
+// buildManifests construit les objets sans vérifier le niveau enforce.
+func (g *Generator) buildManifests() ([]Manifest, error) {
+	var manifests []Manifest
+
+	// Générer le namespace
+	manifests = append(manifests, Manifest{
+		Name:    "00-namespace",
+		Objects: []any{g.generateNamespace()},
+	})
+
+	// Isolation réseau : tout refuser sauf le DNS
+	if g.config.NetworkPolicy.Enabled {
+		manifests = append(manifests, Manifest{
+			Name:    "00-network-policy",
+			Objects: g.generateBaseNetworkPolicies(),
+		})
+	}
+
+	// Générer les PVC, absents si tous sont remplacés par des PVC existants
+	if objects := g.generatePVCs(); len(objects) > 0 {
+		manifests = append(manifests, Manifest{Name: "01-storage", Objects: objects})
+	}
 
 	// Générer cert-manager resources si activé
 	if g.config.CertManager.Enabled {
-		certManagerManifests, err := g.generateCertManager()
+		objects, err := g.generateCertManager()
 		if err != nil {
 			return nil, err
 		}
-		manifests["02-cert-manager"] = certManagerManifests
+		manifests = append(manifests, Manifest{Name: "02-cert-manager", Objects: objects})
 	}
 
-	// Générer les services
-	services := []struct {
-		name   string
-		config config.ServiceConfig
-	}{
-		{"jellyfin", g.config.Services.Jellyfin},
-		{"sonarr", g.config.Services.Sonarr},
-		{"radarr", g.config.Services.Radarr},
-		{"jackett", g.config.Services.Jackett},
-		{"qbittorrent", g.config.Services.QBittorrent},
-	}
-
-	serviceIndex := 3
-	for _, svc := range services {
+	// Générer les services ; le préfixe est le même pour tous afin que
+	// désactiver un service ne renomme pas les fichiers des suivants
+	for _, svc := range g.services() {
 		if !svc.config.Enabled {
 			continue
 		}
 
-		manifest, err := g.generateService(svc.name, svc.config)
+		objects, err := g.generateService(svc.name, svc.config)
 		if err != nil {
 			return nil, err
 		}
-		manifests[fmt.Sprintf("%02d-%s", serviceIndex, svc.name)] = manifest
-		serviceIndex++
+		manifests = append(manifests, Manifest{
+			Name:    "03-" + svc.name,
+			Service: svc.name,
+			Objects: objects,
+		})
 	}
 
 	// Générer l'ingress seulement s'il y a des services exposés
 	if g.config.Ingress.Enabled {
-		ingress, err := g.generateIngress()
-		if err != nil {
-			return nil, err
-		}
 		// Ne pas ajouter l'ingress s'il est vide (aucun service exposé)
-		if ingress != "" {
-			manifests["99-ingress"] = ingress
+		if ingress := g.generateIngress(); ingress != nil {
+			manifests = append(manifests, Manifest{Name: "99-ingress", Objects: []any{ingress}})
 		}
 	}
 
 	return manifests, nil
 }
 
-func (g *Generator) generateNamespace() string {
-	ns := &k8s.Namespace{
-		TypeMeta: k8s.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Namespace",
-		},
-		ObjectMeta: k8s.ObjectMeta{
-			Name: g.config.Namespace,
-		},
+// GenerateAll retourne le contenu YAML de chaque fichier, indexé par nom.
+func (g *Generator) GenerateAll() (map[string]string, error) {
+	list, err := g.Manifests()
+	if err != nil {
+		return nil, err
 	}
 
-	data, _ := yaml.Marshal(ns)
-	return string(data)
+	manifests := make(map[string]string, len(list))
+	for _, m := range list {
+		content, err := renderYAML(m.Objects)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		manifests[m.Name] = content
+	}
+	return manifests, nil
 }
 
-func (g *Generator) generatePVCs() (string, error) {
-	var manifests []string
+// Stream concatène les fichiers générés, dans l'ordre de leur nom (donc
+// d'application), en un seul flux YAML multi-documents.
+func Stream(manifests map[string]string) string {
+	docs := make([]string, 0, len(manifests))
+	for _, name := range sortedKeys(manifests) {
+		docs = append(docs, manifests[name])
+	}
+	return strings.Join(docs, "\n---\n")
+}
 
-	// PVC Media
-	mediaPVC := &k8s.PersistentVolumeClaim{
-		TypeMeta: k8s.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "PersistentVolumeClaim",
-		},
-		ObjectMeta: k8s.ObjectMeta{
-			Name:      "media-pvc",
-			Namespace: g.config.Namespace,
-		},
-		Spec: k8s.PersistentVolumeClaimSpec{
-			AccessModes: g.config.Storage.Media.AccessModes,
-			Resources: k8s.ResourceRequirements{
-				Requests: map[string]string{
-					"storage": g.config.Storage.Media.Size,
-				},
-			},
-			StorageClassName: &g.config.StorageClass,
-		},
+// renderYAML sérialise des objets en un flux YAML multi-documents.
+func renderYAML(objects []any) (string, error) {
+	docs := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(data))
 	}
+	return strings.Join(docs, "\n---\n"), nil
+}
 
-	// PVC Downloads
-	downloadsPVC := &k8s.PersistentVolumeClaim{
+func (g *Generator) generateNamespace() *k8s.Namespace {
+	return &k8s.Namespace{
 		TypeMeta: k8s.TypeMeta{
 			APIVersion: "v1",
-			Kind:       "PersistentVolumeClaim",
+			Kind:       "Namespace",
 		},
 		ObjectMeta: k8s.ObjectMeta{
-			Name:      "downloads-pvc",
-			Namespace: g.config.Namespace,
-		},
-		Spec: k8s.PersistentVolumeClaimSpec{
-			AccessModes: g.config.Storage.Downloads.AccessModes,
-			Resources: k8s.ResourceRequirements{
-				Requests: map[string]string{
-					"storage": g.config.Storage.Downloads.Size,
-				},
-			},
-			StorageClassName: &g.config.StorageClass,
+			Name:   g.config.Namespace,
+			Labels: g.podSecurityLabels(),
 		},
 	}
+}
 
-	mediaData, _ := yaml.Marshal(mediaPVC)
-	downloadsData, _ := yaml.Marshal(downloadsPVC)
-
-	manifests = append(manifests, string(mediaData), "---", string(downloadsData))
+// generatePVCs crée les PVC partagés, sauf ceux remplacés par un PVC
+// existant. Partagés entre plusieurs pods, ils ne sont jamais montés
+// directement : une source hostPath ou nfs passe par un PV statique.
+func (g *Generator) generatePVCs() []any {
+	names := []string{"media", "downloads"}
+	if g.unifiedLayout() {
+		names = []string{"data"}
+	}
 
-	return strings.Join(manifests, "\n"), nil
+	var objects []any
+	for _, name := range names {
+		vol := g.sharedVolume(name)
+		if vol.ExistingClaim != "" {
+			continue
+		}
+		src := vol.VolumeSourceConfig
+		src.PersistentVolume = true
+		objects = append(objects, g.claimObjects(name+"-pvc", sharedClaimSpec(vol), src)...)
+	}
+	return objects
 }
 
-func (g *Generator) generateCertManager() (string, error) {
-	var manifests []string
+func (g *Generator) generateCertManager() ([]any, error) {
+	var objects []any
 
 	// Générer le ClusterIssuer
 	var clusterIssuer *k8s.ClusterIssuer
 
 	if g.config.CertManager.Issuer.Type == "letsencrypt" {
 		if g.config.CertManager.Issuer.Email == "" {
-			return "", fmt.Errorf("email requis pour Let's Encrypt")
+			return nil, fmt.Errorf("email requis pour Let's Encrypt")
 		}
 
 		clusterIssuer = &k8s.ClusterIssuer{
@@ -202,36 +228,17 @@ func (g *Generator) generateCertManager() (string, error) {
 			},
 		}
 	} else {
-		return "", fmt.Errorf("type d'issuer non supporté: %s", g.config.CertManager.Issuer.Type)
+		return nil, fmt.Errorf("type d'issuer non supporté: %s", g.config.CertManager.Issuer.Type)
 	}
-
-	clusterIssuerData, err := yaml.Marshal(clusterIssuer)
-	if err != nil {
-		return "", err
-	}
-	manifests = append(manifests, string(clusterIssuerData))
+	objects = append(objects, clusterIssuer)
 
 	// Générer le Certificate si TLS est activé
 	if g.config.Ingress.TLS.Enabled {
 		dnsNames := []string{}
 
-		// Ajouter Jellyfin si activé ET exposé
-		if g.config.Services.Jellyfin.Enabled && g.config.Services.Jellyfin.Exposed {
-			dnsNames = append(dnsNames, fmt.Sprintf("jellyfin.%s", g.config.Domain))
-		}
-
-		// Ajouter les autres services s'ils sont activés ET exposés
-		services := map[string]config.ServiceConfig{
-			"sonarr":      g.config.Services.Sonarr,
-			"radarr":      g.config.Services.Radarr,
-			"jackett":     g.config.Services.Jackett,
-			"qbittorrent": g.config.Services.QBittorrent,
-		}
-
-		for serviceName, cfg := range services {
-			if cfg.Enabled && cfg.Exposed {
-				dnsNames = append(dnsNames, fmt.Sprintf("%s.%s", serviceName, g.config.Domain))
-			}
+		// Ajouter les services activés ET exposés, dans l'ordre du catalogue
+		for _, svc := range g.exposedServices() {
+			dnsNames = append(dnsNames, fmt.Sprintf("%s.%s", svc.name, g.config.Domain))
 		}
 
 		// Ne créer le certificat que s'il y a des domaines à couvrir
@@ -254,60 +261,44 @@ func (g *Generator) generateCertManager() (string, error) {
 					DNSNames: dnsNames,
 				},
 			}
-
-			certificateData, err := yaml.Marshal(certificate)
-			if err != nil {
-				return "", err
-			}
-			manifests = append(manifests, "---", string(certificateData))
+			objects = append(objects, certificate)
 		}
 	}
 
-	return strings.Join(manifests, "\n"), nil
+	return objects, nil
 }
 
-func (g *Generator) generateService(name string, cfg config.ServiceConfig) (string, error) {
-	var manifests []string
+func (g *Generator) generateService(name string, cfg config.ServiceConfig) ([]any, error) {
+	var objects []any
+
+	// Fichier de configuration initial, copié par un initContainer
+	if cfg.Seed != nil {
+		configMap, err := g.seedConfigMap(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, configMap)
+	}
 
 	// Générer les PVC pour les volumes spécifiques au service
 	for _, vol := range cfg.Volumes {
-		if vol.Size != "" {
-			pvc := &k8s.PersistentVolumeClaim{
-				TypeMeta: k8s.TypeMeta{
-					APIVersion: "v1",
-					Kind:       "PersistentVolumeClaim",
-				},
-				ObjectMeta: k8s.ObjectMeta{
-					Name:      fmt.Sprintf("%s-%s-pvc", name, vol.Name),
-					Namespace: g.config.Namespace,
-				},
-				Spec: k8s.PersistentVolumeClaimSpec{
-					AccessModes: []string{"ReadWriteOnce"},
-					Resources: k8s.ResourceRequirements{
-						Requests: map[string]string{
-							"storage": vol.Size,
-						},
-					},
-					StorageClassName: &g.config.StorageClass,
-				},
-			}
-
-			data, _ := yaml.Marshal(pvc)
-			manifests = append(manifests, string(data), "---")
+		if vol.Size != "" && vol.ExistingClaim == "" && !vol.Inline() {
+			objects = append(objects, g.claimObjects(fmt.Sprintf("%s-%s-pvc", name, vol.Name), serviceClaimSpec(vol), vol.VolumeSourceConfig)...)
 		}
 	}
 
-	// Deployment
-	deployment := g.createDeployment(name, cfg)
-	deploymentData, _ := yaml.Marshal(deployment)
-	manifests = append(manifests, string(deploymentData), "---")
+	// Deployment et Service
+	objects = append(objects, g.createDeployment(name, cfg), g.createService(name, cfg))
+	objects = append(objects, g.createExternalServices(name, cfg)...)
 
-	// Service
-	service := g.createService(name, cfg)
-	serviceData, _ := yaml.Marshal(service)
-	manifests = append(manifests, string(serviceData))
+	if g.config.NetworkPolicy.Enabled {
+		objects = append(objects, g.serviceNetworkPolicy(name, cfg))
+		if g.config.Ingress.Enabled && cfg.Exposed {
+			objects = append(objects, g.ingressNetworkPolicy(name, cfg))
+		}
+	}
 
-	return strings.Join(manifests, "\n"), nil
+	return objects, nil
 }
 
 func (g *Generator) createDeployment(name string, cfg config.ServiceConfig) *k8s.Deployment {
@@ -316,44 +307,51 @@ func (g *Generator) createDeployment(name string, cfg config.ServiceConfig) *k8s
 		"component": "teleflix",
 	}
 
-	// Construire les variables d'environnement
-	var envVars []k8s.EnvVar
+	// Construire les variables d'environnement (triées pour une sortie stable)
+	env := g.linkEnvironment(cfg, g.internalURL)
+	for key, value := range portEnvironment(cfg) {
+		env[key] = value
+	}
 	for key, value := range cfg.Environment {
+		env[key] = value
+	}
+
+	var envVars []k8s.EnvVar
+	for _, key := range sortedKeys(env) {
 		envVars = append(envVars, k8s.EnvVar{
 			Name:  key,
-			Value: value,
+			Value: env[key],
 		})
 	}
 
 	// Construire les volumes et volume mounts
-	var volumes []k8s.Volume
-	var volumeMounts []k8s.VolumeMount
+	volumeMounts, volumeDevices, volumes := g.podVolumes(name, cfg)
 
-	for _, vol := range cfg.Volumes {
-		volumeMounts = append(volumeMounts, k8s.VolumeMount{
-			Name:      vol.Name,
-			MountPath: vol.MountPath,
-			ReadOnly:  vol.ReadOnly,
+	// Racine en lecture seule : /tmp reste inscriptible
+	if cfg.Security.ReadOnlyRootFilesystem {
+		volumeMounts = append(volumeMounts, k8s.VolumeMount{Name: "tmp", MountPath: "/tmp"})
+		volumes = append(volumes, k8s.Volume{
+			Name:         "tmp",
+			VolumeSource: k8s.VolumeSource{EmptyDir: &k8s.EmptyDirVolumeSource{}},
 		})
+	}
 
-		// Déterminer le nom du PVC
-		var pvcName string
-		if vol.Name == "media" {
-			pvcName = "media-pvc"
-		} else if vol.Name == "downloads" {
-			pvcName = "downloads-pvc"
-		} else {
-			pvcName = fmt.Sprintf("%s-%s-pvc", name, vol.Name)
+	var initContainers []k8s.Container
+	if cfg.Seed != nil {
+		container, volume := seedContainer(name, cfg, volumeMounts)
+		initContainers = append(initContainers, container)
+		volumes = append(volumes, volume)
+	}
+	if cfg.VPN.Enabled {
+		initContainers = append(initContainers, vpnSidecar(name, cfg))
+		if cfg.VPN.HostTun {
+			volumes = append(volumes, vpnVolume())
 		}
+	}
 
-		volumes = append(volumes, k8s.Volume{
-			Name: vol.Name,
-			VolumeSource: k8s.VolumeSource{
-				PersistentVolumeClaim: &k8s.PersistentVolumeClaimVolumeSource{
-					ClaimName: pvcName,
-				},
-			},
-		})
+	replicas := int32(1)
+	if cfg.Replicas != nil {
+		replicas = *cfg.Replicas
 	}
 
 	return &k8s.Deployment{
@@ -367,27 +365,26 @@ func (g *Generator) createDeployment(name string, cfg config.ServiceConfig) *k8s
 			Labels:    labels,
 		},
 		Spec: k8s.DeploymentSpec{
-			Replicas: int32Ptr(1),
+			Replicas: int32Ptr(replicas),
 			Selector: &k8s.LabelSelector{
 				MatchLabels: labels,
 			},
+			Strategy: g.deploymentStrategy(name, cfg),
 			Template: k8s.PodTemplateSpec{
 				ObjectMeta: k8s.ObjectMeta{
 					Labels: labels,
 				},
 				Spec: k8s.PodSpec{
+					SecurityContext: podSecurityContext(cfg.Security, env),
+					InitContainers:  initContainers,
 					Containers: []k8s.Container{
 						{
-							Name:  name,
-							Image: fmt.Sprintf("%s:%s", cfg.Image, cfg.Tag),
-							Ports: []k8s.ContainerPort{
-								{
-									ContainerPort: cfg.Port,
-									Protocol:      "TCP",
-								},
-							},
-							Env:          envVars,
-							VolumeMounts: volumeMounts,
+							Name:          name,
+							Image:         fmt.Sprintf("%s:%s", cfg.Image, cfg.Tag),
+							Ports:         containerPorts(cfg),
+							Env:           envVars,
+							VolumeMounts:  volumeMounts,
+							VolumeDevices: volumeDevices,
 							Resources: k8s.ResourceRequirements{
 								Requests: map[string]string{
 									"cpu":    cfg.Resources.Requests.CPU,
@@ -398,6 +395,10 @@ func (g *Generator) createDeployment(name string, cfg config.ServiceConfig) *k8s
 									"memory": cfg.Resources.Limits.Memory,
 								},
 							},
+							LivenessProbe:   newProbe(cfg, cfg.Probes.Liveness, livenessTiming),
+							ReadinessProbe:  newProbe(cfg, cfg.Probes.Readiness, readinessTiming),
+							StartupProbe:    newProbe(cfg, cfg.Probes.Startup, startupTiming),
+							SecurityContext: containerSecurityContext(cfg.Security),
 						},
 					},
 					Volumes: volumes,
@@ -407,100 +408,151 @@ func (g *Generator) createDeployment(name string, cfg config.ServiceConfig) *k8s
 	}
 }
 
+// Délais par défaut des sondes. La sonde startup laisse 5 minutes au premier
+// démarrage (migrations de base des *arr) avant que liveness ne prenne le relais.
+var (
+	livenessTiming  = config.ProbeTiming{PeriodSeconds: 30, TimeoutSeconds: 5, FailureThreshold: 3}
+	readinessTiming = config.ProbeTiming{PeriodSeconds: 10, TimeoutSeconds: 5, FailureThreshold: 3}
+	startupTiming   = config.ProbeTiming{PeriodSeconds: 10, TimeoutSeconds: 5, FailureThreshold: 30}
+)
+
+// newProbe construit une sonde à partir de la vérification déclarée pour le
+// service ; les délais non renseignés prennent leur valeur par défaut.
+func newProbe(cfg config.ServiceConfig, timing, defaults config.ProbeTiming) *k8s.Probe {
+	port := cfg.Probes.Port
+	if port == 0 {
+		port = cfg.Port
+	}
+
+	var handler k8s.ProbeHandler
+	switch cfg.Probes.Type {
+	case "http":
+		handler.HTTPGet = &k8s.HTTPGetAction{Path: cfg.Probes.Path, Port: port}
+	case "tcp":
+		handler.TCPSocket = &k8s.TCPSocketAction{Port: port}
+	default:
+		return nil // Aucune sonde (type vide ou none)
+	}
+
+	probe := &k8s.Probe{
+		ProbeHandler:        handler,
+		InitialDelaySeconds: timing.InitialDelaySeconds,
+		PeriodSeconds:       timing.PeriodSeconds,
+		TimeoutSeconds:      timing.TimeoutSeconds,
+		FailureThreshold:    timing.FailureThreshold,
+	}
+	if probe.PeriodSeconds == 0 {
+		probe.PeriodSeconds = defaults.PeriodSeconds
+	}
+	if probe.TimeoutSeconds == 0 {
+		probe.TimeoutSeconds = defaults.TimeoutSeconds
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = defaults.FailureThreshold
+	}
+	return probe
+}
+
+// podSecurityContext fixe l'identité du pod. Sans valeur explicite,
+// l'utilisateur et le groupe sont repris des variables PUID/PGID des images
+// linuxserver.io, qui acceptent alors de démarrer sans root.
+func podSecurityContext(sec config.SecurityConfig, env map[string]string) *k8s.PodSecurityContext {
+	psc := &k8s.PodSecurityContext{
+		RunAsUser:      sec.RunAsUser,
+		RunAsGroup:     sec.RunAsGroup,
+		FSGroup:        sec.FSGroup,
+		SeccompProfile: &k8s.SeccompProfile{Type: "RuntimeDefault"},
+	}
+	if sec.SeccompProfile != "" {
+		psc.SeccompProfile.Type = sec.SeccompProfile
+	}
+	if psc.RunAsUser == nil {
+		psc.RunAsUser = parseID(env["PUID"])
+	}
+	if psc.RunAsGroup == nil {
+		psc.RunAsGroup = parseID(env["PGID"])
+	}
+	if psc.FSGroup == nil {
+		psc.FSGroup = psc.RunAsGroup // Volumes accessibles au groupe du conteneur
+	}
+	if psc.RunAsUser != nil && *psc.RunAsUser != 0 {
+		psc.RunAsNonRoot = boolPtr(true)
+	}
+	return psc
+}
+
+// containerSecurityContext retire toutes les capabilities par défaut et
+// interdit l'élévation de privilèges, comme l'exige le profil restricted.
+func containerSecurityContext(sec config.SecurityConfig) *k8s.SecurityContext {
+	drop := sec.Capabilities.Drop
+	if drop == nil {
+		drop = []string{"ALL"}
+	}
+	sc := &k8s.SecurityContext{
+		AllowPrivilegeEscalation: boolPtr(sec.AllowPrivilegeEscalation),
+		Capabilities: &k8s.Capabilities{
+			Add:  sec.Capabilities.Add,
+			Drop: drop,
+		},
+	}
+	if sec.ReadOnlyRootFilesystem {
+		sc.ReadOnlyRootFilesystem = boolPtr(true)
+	}
+	return sc
+}
+
+// parseID lit un identifiant numérique ; une valeur absente ou invalide
+// laisse le choix à l'image.
+func parseID(s string) *int64 {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 0 {
+		return nil
+	}
+	return &id
+}
+
 func (g *Generator) createService(name string, cfg config.ServiceConfig) *k8s.Service {
 	labels := map[string]string{
 		"app":       name,
 		"component": "teleflix",
 	}
 
+	// Le nodePort configuré concerne le port principal
+	ports := mainServicePorts(cfg)
+	ports[0].NodePort = cfg.Service.NodePort
+
 	return &k8s.Service{
 		TypeMeta: k8s.TypeMeta{
 			APIVersion: "v1",
 			Kind:       "Service",
 		},
 		ObjectMeta: k8s.ObjectMeta{
-			Name:      name,
-			Namespace: g.config.Namespace,
-			Labels:    labels,
+			Name:        name,
+			Namespace:   g.config.Namespace,
+			Labels:      labels,
+			Annotations: cfg.Service.Annotations,
 		},
 		Spec: k8s.ServiceSpec{
-			Selector: labels,
-			Ports: []k8s.ServicePort{
-				{
-					Port:       cfg.Port,
-					TargetPort: int32(cfg.Port),
-					Protocol:   "TCP",
-				},
-			},
+			Type:                  cfg.Service.Type,
+			Selector:              labels,
+			Ports:                 ports,
+			LoadBalancerIP:        cfg.Service.LoadBalancerIP,
+			ExternalTrafficPolicy: cfg.Service.ExternalTrafficPolicy,
 		},
 	}
 }
 
-func (g *Generator) generateIngress() (string, error) {
+func (g *Generator) generateIngress() *k8s.Ingress {
 	rules := []k8s.IngressRule{}
 
-	// Ajouter Jellyfin si activé ET exposé
-	if g.config.Services.Jellyfin.Enabled && g.config.Services.Jellyfin.Exposed {
-		rules = append(rules, k8s.IngressRule{
-			Host: fmt.Sprintf("jellyfin.%s", g.config.Domain),
-			IngressRuleValue: k8s.IngressRuleValue{
-				HTTP: &k8s.HTTPIngressRuleValue{
-					Paths: []k8s.HTTPIngressPath{
-						{
-							Path:     "/",
-							PathType: pathTypePtr("Prefix"),
-							Backend: k8s.IngressBackend{
-								Service: &k8s.IngressServiceBackend{
-									Name: "jellyfin",
-									Port: k8s.ServiceBackendPort{
-										Number: g.config.Services.Jellyfin.Port,
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-		})
-	}
-
-	// Ajouter les autres services s'ils sont activés ET exposés
-	services := map[string]config.ServiceConfig{
-		"sonarr":      g.config.Services.Sonarr,
-		"radarr":      g.config.Services.Radarr,
-		"jackett":     g.config.Services.Jackett,
-		"qbittorrent": g.config.Services.QBittorrent,
-	}
-
-	for serviceName, cfg := range services {
-		if cfg.Enabled && cfg.Exposed {
-			rules = append(rules, k8s.IngressRule{
-				Host: fmt.Sprintf("%s.%s", serviceName, g.config.Domain),
-				IngressRuleValue: k8s.IngressRuleValue{
-					HTTP: &k8s.HTTPIngressRuleValue{
-						Paths: []k8s.HTTPIngressPath{
-							{
-								Path:     "/",
-								PathType: pathTypePtr("Prefix"),
-								Backend: k8s.IngressBackend{
-									Service: &k8s.IngressServiceBackend{
-										Name: serviceName,
-										Port: k8s.ServiceBackendPort{
-											Number: cfg.Port,
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			})
-		}
+	// Ajouter les services activés ET exposés, dans l'ordre du catalogue
+	for _, svc := range g.exposedServices() {
+		rules = append(rules, g.ingressRule(svc.name, svc.config))
 	}
 
 	// Si aucun service n'est exposé, ne pas créer d'ingress
 	if len(rules) == 0 {
-		return "", nil
+		return nil
 	}
 
 	// Préparer les annotations avec celles par défaut
@@ -559,18 +611,103 @@ func (g *Generator) generateIngress() (string, error) {
 		}
 	}
 
-	data, err := yaml.Marshal(ingress)
-	if err != nil {
-		return "", err
+	return ingress
+}
+
+// ingressRule route le sous-domaine d'un service vers son Service Kubernetes.
+func (g *Generator) ingressRule(name string, cfg config.ServiceConfig) k8s.IngressRule {
+	return k8s.IngressRule{
+		Host: fmt.Sprintf("%s.%s", name, g.config.Domain),
+		IngressRuleValue: k8s.IngressRuleValue{
+			HTTP: &k8s.HTTPIngressRuleValue{
+				Paths: []k8s.HTTPIngressPath{
+					{
+						Path:     "/",
+						PathType: pathTypePtr("Prefix"),
+						Backend: k8s.IngressBackend{
+							Service: &k8s.IngressServiceBackend{
+								Name: name,
+								Port: k8s.ServiceBackendPort{
+									Number: cfg.Port,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
+}
+
+// namedService associe un service à son nom dans le catalogue.
+type namedService struct {
+	name   string
+	config config.ServiceConfig
+}
+
+// services retourne tous les services du catalogue dans un ordre fixe, afin
+// que les manifests générés soient reproductibles d'une exécution à l'autre.
+func (g *Generator) services() []namedService {
+	var services []namedService
+	for _, name := range g.config.ServiceNames() {
+		services = append(services, namedService{name, g.config.Services[name]})
+	}
+	return services
+}
 
-	return string(data), nil
+// exposedServices retourne les services activés et exposés via l'ingress.
+func (g *Generator) exposedServices() []namedService {
+	var exposed []namedService
+	for _, svc := range g.services() {
+		if svc.config.Enabled && svc.config.Exposed {
+			exposed = append(exposed, svc)
+		}
+	}
+	return exposed
+}
+
+// linkEnvironment retourne les URL internes des services liés, pour chaque
+// lien déclarant une variable d'environnement. Les services désactivés sont
+// ignorés ; une valeur explicite dans environment reste prioritaire.
+func (g *Generator) linkEnvironment(cfg config.ServiceConfig, urlFor func(string, config.ServiceConfig) string) map[string]string {
+	env := make(map[string]string)
+	for _, link := range cfg.Links {
+		target, ok := g.config.Services[link.Service]
+		if link.Env == "" || !ok || !target.Enabled {
+			continue
+		}
+		env[link.Env] = urlFor(link.Service, target)
+	}
+	return env
+}
+
+// internalURL retourne l'adresse d'un service depuis l'intérieur du cluster.
+func (g *Generator) internalURL(name string, cfg config.ServiceConfig) string {
+	return fmt.Sprintf("http://%s.%s.svc:%d", name, g.config.Namespace, cfg.Port)
+}
+
+// sortedKeys retourne les clés d'une map triées par ordre alphabétique.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func int32Ptr(i int32) *int32 {
 	return &i
 }
 
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
 func pathTypePtr(pt string) *string {
 	return &pt
 }
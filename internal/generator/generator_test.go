@@ -0,0 +1,566 @@
+package generator
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"teleflix/internal/config"
+	"teleflix/internal/k8s"
+)
+
+// Régénérer les fichiers de référence avec :
+//
+//	go test ./internal/generator -update
+var update = flag.Bool("update", false, "met à jour les fichiers golden dans testdata/golden")
+
+// goldenCases retourne les configurations de test présentes dans dir.
+func goldenCases(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("aucune configuration de test dans %s", dir)
+	}
+	return files
+}
+
+func loadConfig(t *testing.T, configFile string) *config.Config {
+	t.Helper()
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		t.Fatalf("chargement de %s: %v", configFile, err)
+	}
+	return cfg
+}
+
+func generate(t *testing.T, configFile string) map[string]string {
+	t.Helper()
+
+	manifests, err := New(loadConfig(t, configFile)).GenerateAll()
+	if err != nil {
+		t.Fatalf("génération de %s: %v", configFile, err)
+	}
+	return manifests
+}
+
+// checkGolden compare les fichiers générés au contenu de goldenDir, ou
+// réécrit goldenDir avec -update.
+func checkGolden(t *testing.T, goldenDir string, manifests map[string]string) {
+	t.Helper()
+
+	if *update {
+		if err := os.RemoveAll(goldenDir); err != nil {
+			t.Fatal(err)
+		}
+		for file, content := range manifests {
+			path := filepath.Join(goldenDir, file+".yaml")
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var want []string
+	err := filepath.WalkDir(goldenDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(goldenDir, path)
+		if err != nil {
+			return err
+		}
+		want = append(want, strings.TrimSuffix(filepath.ToSlash(rel), ".yaml"))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("lecture de %s (lancer avec -update ?): %v", goldenDir, err)
+	}
+
+	var got []string
+	for file := range manifests {
+		got = append(got, file)
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("fichiers générés = %v, attendu %v", got, want)
+	}
+
+	for _, file := range want {
+		expected, err := os.ReadFile(filepath.Join(goldenDir, file+".yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if manifests[file] != string(expected) {
+			t.Errorf("%s.yaml diffère du fichier golden:\n--- obtenu ---\n%s\n--- attendu ---\n%s",
+				file, manifests[file], expected)
+		}
+	}
+}
+
+func TestGenerateAllGolden(t *testing.T) {
+	for _, configFile := range goldenCases(t, "testdata") {
+		name := strings.TrimSuffix(filepath.Base(configFile), ".yaml")
+
+		t.Run(name, func(t *testing.T) {
+			checkGolden(t, filepath.Join("testdata", "golden", name), generate(t, configFile))
+		})
+	}
+}
+
+func TestGenerateComposeGolden(t *testing.T) {
+	for _, configFile := range goldenCases(t, filepath.Join("testdata", "compose")) {
+		name := strings.TrimSuffix(filepath.Base(configFile), ".yaml")
+
+		t.Run(name, func(t *testing.T) {
+			manifests, err := New(loadConfig(t, configFile)).GenerateCompose()
+			if err != nil {
+				t.Fatalf("génération de %s: %v", configFile, err)
+			}
+			checkGolden(t, filepath.Join("testdata", "golden", "compose", name), manifests)
+		})
+	}
+}
+
+// Chaque cas de testdata/kustomize est un répertoire contenant base.yaml et
+// un fichier par overlay.
+func TestGenerateKustomizeGolden(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "kustomize", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range dirs {
+		name := filepath.Base(dir)
+
+		t.Run(name, func(t *testing.T) {
+			var overlays []Overlay
+			for _, configFile := range goldenCases(t, dir) {
+				env := strings.TrimSuffix(filepath.Base(configFile), ".yaml")
+				if env != "base" {
+					overlays = append(overlays, Overlay{Name: env, Config: loadConfig(t, configFile)})
+				}
+			}
+
+			gen := New(loadConfig(t, filepath.Join(dir, "base.yaml")))
+			manifests, err := gen.GenerateKustomize(overlays)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, filepath.Join("testdata", "golden", "kustomize", name), manifests)
+		})
+	}
+}
+
+func TestGenerateComposeRejectsHostPortCollisions(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "compose", "default.yaml"))
+	for _, name := range []string{"jellyseerr", "overseerr"} {
+		svc, _ := config.Preset(name)
+		svc.Enabled = true
+		cfg.Services[name] = svc
+	}
+
+	_, err := New(cfg).GenerateCompose()
+	if err == nil || !strings.Contains(err.Error(), "5055/tcp") {
+		t.Fatalf("collision attendue sur le port hôte 5055/tcp, obtenu: %v", err)
+	}
+
+	cfg.Compose.PublishPorts = false
+	if _, err := New(cfg).GenerateCompose(); err != nil {
+		t.Fatalf("sans publication, aucun port hôte n'est partagé: %v", err)
+	}
+}
+
+func TestGenerateKustomizeRejectsUnsupportedChanges(t *testing.T) {
+	base := loadConfig(t, filepath.Join("testdata", "default.yaml"))
+	overlay := loadConfig(t, filepath.Join("testdata", "default.yaml"))
+	sonarr := overlay.Services["sonarr"]
+	sonarr.Tag = "develop"
+	overlay.Services["sonarr"] = sonarr
+
+	_, err := New(base).GenerateKustomize([]Overlay{{Name: "dev", Config: overlay}})
+	if err == nil || !strings.Contains(err.Error(), "03-sonarr") {
+		t.Fatalf("erreur attendue sur 03-sonarr, obtenu: %v", err)
+	}
+}
+
+func TestPodSecurityEnforceAndWarnings(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "default.yaml"))
+	qbittorrent := cfg.Services["qbittorrent"]
+	qbittorrent.Security.Capabilities.Add = []string{"NET_ADMIN"}
+	cfg.Services["qbittorrent"] = qbittorrent
+
+	// NET_ADMIN est refusé dès le niveau baseline
+	cfg.PodSecurity.Enforce = "baseline"
+	_, err := New(cfg).Manifests()
+	if err == nil || !strings.Contains(err.Error(), "Deployment qbittorrent: containers[qbittorrent].securityContext.capabilities.add") {
+		t.Fatalf("erreur attendue sur qbittorrent, obtenu: %v", err)
+	}
+
+	// Avec enforce privileged, seul warn/audit le signale
+	cfg.PodSecurity.Enforce = "privileged"
+	warnings, err := New(cfg).Warnings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "NET_ADMIN") || !strings.Contains(warnings[0], "podSecurity.warn") {
+		t.Fatalf("un avertissement NET_ADMIN attendu, obtenu %v", warnings)
+	}
+
+	// Les Pod Security Standards ne concernent pas docker-compose
+	if warnings := New(cfg).ConfigWarnings(); len(warnings) != 0 {
+		t.Fatalf("aucun avertissement hors Pod Security attendu, obtenu %v", warnings)
+	}
+}
+
+func TestComposeKeepsConfigWarnings(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "compose", "default.yaml"))
+	radarr := cfg.Services["radarr"]
+	radarr.Volumes[1].MountPath = "/data/downloads"
+	cfg.Services["radarr"] = radarr
+
+	if _, err := New(cfg).GenerateCompose(); err != nil {
+		t.Fatal(err)
+	}
+	warnings := New(cfg).ConfigWarnings()
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "services.radarr.volumes: downloads-pvc monté sous /data/downloads") {
+		t.Fatalf("avertissement de chemin attendu sur radarr, obtenu %q", warnings)
+	}
+}
+
+func TestVPNSkipsExternalPorts(t *testing.T) {
+	manifests := generate(t, filepath.Join("testdata", "vpn.yaml"))
+	if strings.Contains(manifests["03-qbittorrent"], "qbittorrent-lb") {
+		t.Error("aucun Service LoadBalancer attendu derrière le VPN")
+	}
+
+	cfg := loadConfig(t, filepath.Join("testdata", "vpn.yaml"))
+	warnings, err := New(cfg).Warnings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(warnings, func(w string) bool {
+		return strings.HasPrefix(w, "services.qbittorrent.ports[0]: serviceType LoadBalancer ignoré")
+	}) {
+		t.Fatalf("avertissement attendu sur services.qbittorrent.ports[0], obtenu %v", warnings)
+	}
+}
+
+func TestVPNForwardedPortReplacesTorrentPort(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "vpn.yaml"))
+	deployment := New(cfg).createDeployment("qbittorrent", cfg.Services["qbittorrent"])
+
+	// Le port redirigé doit être cohérent entre l'application, le conteneur et le pare-feu
+	env := func(c k8s.Container, name string) string {
+		for _, e := range c.Env {
+			if e.Name == name {
+				return e.Value
+			}
+		}
+		return ""
+	}
+	spec := deployment.Spec.Template.Spec
+	app, vpn := spec.Containers[0], spec.InitContainers[0]
+	if got := env(app, "TORRENTING_PORT"); got != "51413" {
+		t.Errorf("TORRENTING_PORT = %q, attendu 51413", got)
+	}
+	torrent := 0
+	for _, p := range app.Ports {
+		if p.Name == "torrent" || p.Name == "torrent-udp" {
+			torrent++
+			if p.ContainerPort != 51413 {
+				t.Errorf("containerPort %s = %d, attendu 51413", p.Name, p.ContainerPort)
+			}
+		}
+	}
+	if torrent != 2 {
+		t.Errorf("ports torrent TCP et UDP attendus, obtenu %v", app.Ports)
+	}
+	if got := env(vpn, "FIREWALL_VPN_INPUT_PORTS"); got != "51413" {
+		t.Errorf("FIREWALL_VPN_INPUT_PORTS = %q, attendu 51413", got)
+	}
+}
+
+func TestVPNSidecarHardening(t *testing.T) {
+	for file, hostTun := range map[string]bool{
+		"vpn.yaml":                true,
+		"vpn-portforwarding.yaml": false,
+	} {
+		cfg := loadConfig(t, filepath.Join("testdata", file))
+		deployment := New(cfg).createDeployment("qbittorrent", cfg.Services["qbittorrent"])
+		spec := deployment.Spec.Template.Spec
+		vpn := spec.InitContainers[0]
+
+		caps := vpn.SecurityContext.Capabilities
+		if !reflect.DeepEqual(caps.Drop, []string{"ALL"}) || !slices.Contains(caps.Add, "NET_ADMIN") {
+			t.Errorf("%s: capabilities = %+v, attendu drop ALL et add NET_ADMIN", file, caps)
+		}
+		if ape := vpn.SecurityContext.AllowPrivilegeEscalation; ape == nil || *ape {
+			t.Errorf("%s: allowPrivilegeEscalation doit valoir false", file)
+		}
+		// Le périphérique TUN de l'hôte n'est monté qu'avec vpn.hostTun
+		mounted := slices.ContainsFunc(spec.Volumes, func(v k8s.Volume) bool { return v.HostPath != nil })
+		if mounted != hostTun || (len(vpn.VolumeMounts) > 0) != hostTun {
+			t.Errorf("%s: hostPath /dev/net/tun monté = %v, attendu %v", file, mounted, hostTun)
+		}
+	}
+}
+
+func TestVPNOpensExtraPorts(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "vpn.yaml"))
+	qbittorrent := cfg.Services["qbittorrent"]
+	qbittorrent.Ports = append(slices.Clone(qbittorrent.Ports), config.PortConfig{Name: "metrics", Port: 9090})
+
+	// Le port supplémentaire passe hors du tunnel, les ports torrent
+	// remplacés par le port redirigé restent limités au tunnel
+	env := make(map[string]string)
+	for _, e := range vpnSidecar("qbittorrent", qbittorrent).Env {
+		env[e.Name] = e.Value
+	}
+	if got := env["FIREWALL_INPUT_PORTS"]; got != "8080,9090" {
+		t.Errorf("FIREWALL_INPUT_PORTS = %q, attendu 8080,9090", got)
+	}
+	if got := env["FIREWALL_VPN_INPUT_PORTS"]; got != "51413" {
+		t.Errorf("FIREWALL_VPN_INPUT_PORTS = %q, attendu 51413", got)
+	}
+}
+
+func TestVPNKeepsClusterReachable(t *testing.T) {
+	for file, want := range map[string]string{
+		"vpn-portforwarding.yaml": "10.42.0.0/16,10.43.0.0/16", // Plages par défaut de k3s
+		"vpn.yaml":                "10.244.0.0/16,10.96.0.0/12",
+	} {
+		cfg := loadConfig(t, filepath.Join("testdata", file))
+		env := make(map[string]string)
+		for _, e := range vpnSidecar("qbittorrent", cfg.Services["qbittorrent"]).Env {
+			env[e.Name] = e.Value
+		}
+		if env["FIREWALL_OUTBOUND_SUBNETS"] != want {
+			t.Errorf("%s: FIREWALL_OUTBOUND_SUBNETS = %q, attendu %q", file, env["FIREWALL_OUTBOUND_SUBNETS"], want)
+		}
+		if env["DNS_KEEP_NAMESERVER"] != "on" {
+			t.Errorf("%s: DNS_KEEP_NAMESERVER = %q, attendu on", file, env["DNS_KEEP_NAMESERVER"])
+		}
+	}
+}
+
+func TestNetworkPolicyLinksOpenExtraPorts(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "default.yaml"))
+	cfg.NetworkPolicy = config.NetworkPolicyConfig{Enabled: true, IngressNamespace: "kube-system"}
+	g := New(cfg)
+
+	// Le port UDP de qBittorrent doit être ouvert des deux côtés du lien
+	torrent := func(ports []k8s.NetworkPolicyPort) bool {
+		return slices.ContainsFunc(ports, func(p k8s.NetworkPolicyPort) bool {
+			return p.Protocol == "UDP" && *p.Port == 6881
+		})
+	}
+	ingress := g.serviceNetworkPolicy("qbittorrent", cfg.Services["qbittorrent"]).Spec.Ingress
+	if len(ingress) == 0 || !torrent(ingress[0].Ports) {
+		t.Errorf("entrée depuis les liens sans le port UDP 6881: %+v", ingress)
+	}
+	egress := g.serviceNetworkPolicy("sonarr", cfg.Services["sonarr"]).Spec.Egress
+	if !slices.ContainsFunc(egress, func(r k8s.NetworkPolicyEgressRule) bool {
+		return r.To[0].PodSelector != nil && r.To[0].PodSelector.MatchLabels["app"] == "qbittorrent" && torrent(r.Ports)
+	}) {
+		t.Errorf("sortie vers qbittorrent sans le port UDP 6881: %+v", egress)
+	}
+}
+
+func TestNetworkPolicyDNSTarget(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "default.yaml"))
+	cfg.NetworkPolicy = config.NetworkPolicyConfig{Enabled: true, IngressNamespace: "kube-system"}
+
+	dnsPeer := func() k8s.NetworkPolicyPeer {
+		for _, p := range New(cfg).generateBaseNetworkPolicies() {
+			if np := p.(*k8s.NetworkPolicy); np.Name == "allow-dns" {
+				return np.Spec.Egress[0].To[0]
+			}
+		}
+		t.Fatal("NetworkPolicy allow-dns absente")
+		return k8s.NetworkPolicyPeer{}
+	}
+
+	// CoreDNS de kube-system par défaut
+	peer := dnsPeer()
+	if peer.NamespaceSelector.MatchLabels[namespaceLabel] != "kube-system" || peer.PodSelector.MatchLabels["k8s-app"] != "kube-dns" {
+		t.Errorf("cible DNS par défaut inattendue: %+v %+v", peer.NamespaceSelector, peer.PodSelector)
+	}
+
+	cfg.NetworkPolicy.DNSNamespace = "dns"
+	cfg.NetworkPolicy.DNSSelector = map[string]string{"app.kubernetes.io/name": "coredns"}
+	peer = dnsPeer()
+	if peer.NamespaceSelector.MatchLabels[namespaceLabel] != "dns" || !reflect.DeepEqual(peer.PodSelector.MatchLabels, cfg.NetworkPolicy.DNSSelector) {
+		t.Errorf("cible DNS configurée ignorée: %+v %+v", peer.NamespaceSelector, peer.PodSelector)
+	}
+}
+
+func TestPathMappingWarnings(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "default.yaml"))
+	radarr := cfg.Services["radarr"]
+	radarr.Volumes[1].MountPath = "/data/downloads"
+	cfg.Services["radarr"] = radarr
+	sonarr := cfg.Services["sonarr"]
+	sonarr.Volumes = slices.DeleteFunc(slices.Clone(sonarr.Volumes), func(v config.VolumeConfig) bool {
+		return v.Name == "downloads"
+	})
+	cfg.Services["sonarr"] = sonarr
+
+	warnings := New(cfg).pathMappingWarnings()
+	want := []string{
+		"services.sonarr.volumes: aucun accès aux téléchargements de qbittorrent (downloads-pvc), les imports échoueront",
+		"services.radarr.volumes: downloads-pvc monté sous /data/downloads alors que qbittorrent l'utilise sous /downloads, aligner les mountPath ou déclarer un Remote Path Mapping",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Fatalf("avertissements = %q, attendu %q", warnings, want)
+	}
+
+	// Les chemins sont comparés sans lien déclaré, et même si le client
+	// monte aussi les médias
+	setDownloads := func(cfg *config.Config, name, mountPath string) {
+		svc := cfg.Services[name]
+		svc.Volumes = slices.Clone(svc.Volumes)
+		for i := range svc.Volumes {
+			if svc.Volumes[i].Name == "downloads" {
+				svc.Volumes[i].MountPath = mountPath
+			}
+		}
+		cfg.Services[name] = svc
+	}
+	for _, tt := range []struct {
+		name   string
+		change func(cfg *config.Config)
+		want   string
+	}{
+		{"*arr sans lien", func(cfg *config.Config) {
+			sonarr := cfg.Services["sonarr"]
+			sonarr.Links = slices.DeleteFunc(slices.Clone(sonarr.Links), func(l config.LinkConfig) bool {
+				return l.Service == "qbittorrent"
+			})
+			cfg.Services["sonarr"] = sonarr
+			setDownloads(cfg, "sonarr", "/data/downloads")
+		}, "services.sonarr.volumes: downloads-pvc monté sous /data/downloads alors que qbittorrent l'utilise sous /downloads, aligner les mountPath ou déclarer un Remote Path Mapping"},
+		{"client montant les médias", func(cfg *config.Config) {
+			qbittorrent := cfg.Services["qbittorrent"]
+			qbittorrent.Volumes = append(slices.Clone(qbittorrent.Volumes), config.VolumeConfig{Name: "media", MountPath: "/media"})
+			cfg.Services["qbittorrent"] = qbittorrent
+			setDownloads(cfg, "radarr", "/data/downloads")
+		}, "services.radarr.volumes: downloads-pvc monté sous /data/downloads alors que qbittorrent l'utilise sous /downloads, aligner les mountPath ou déclarer un Remote Path Mapping"},
+	} {
+		cfg := loadConfig(t, filepath.Join("testdata", "default.yaml"))
+		tt.change(cfg)
+		if warnings := New(cfg).pathMappingWarnings(); !reflect.DeepEqual(warnings, []string{tt.want}) {
+			t.Errorf("%s: avertissements = %q, attendu %q", tt.name, warnings, tt.want)
+		}
+	}
+
+	// La disposition unified monte les téléchargements au même chemin partout
+	cfg.Storage.Layout = "unified"
+	cfg.Services["sonarr"] = loadConfig(t, filepath.Join("testdata", "default.yaml")).Services["sonarr"]
+	if warnings := New(cfg).pathMappingWarnings(); len(warnings) != 0 {
+		t.Fatalf("aucun avertissement attendu, obtenu %q", warnings)
+	}
+}
+
+func TestDeploymentStrategyFollowsVolumes(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "default.yaml"))
+	sonarr := cfg.Services["sonarr"]
+	sonarr.Replicas = int32Ptr(2)
+	cfg.Services["sonarr"] = sonarr
+	radarr := cfg.Services["radarr"]
+	radarr.Strategy = "RollingUpdate"
+	cfg.Services["radarr"] = radarr
+
+	g := New(cfg)
+	for name, want := range map[string]string{"sonarr": "Recreate", "radarr": "RollingUpdate"} {
+		if got := g.deploymentStrategy(name, cfg.Services[name]); got == nil || got.Type != want {
+			t.Errorf("stratégie de %s = %v, attendu %s", name, got, want)
+		}
+	}
+
+	want := []string{"services.sonarr.replicas: 2 réplicas avec des volumes ReadWriteOnce (sonarr-config-pvc, downloads-pvc), seul le premier pod pourra les monter"}
+	if warnings := g.strategyWarnings(); !reflect.DeepEqual(warnings, want) {
+		t.Fatalf("avertissements = %q, attendu %q", warnings, want)
+	}
+
+	// Sans volume exclusif, la stratégie par défaut de Kubernetes est conservée
+	jellyfin := cfg.Services["jellyfin"]
+	jellyfin.Volumes = jellyfin.Volumes[:1]
+	if got := g.deploymentStrategy("jellyfin", jellyfin); got != nil {
+		t.Errorf("aucune stratégie attendue pour jellyfin, obtenu %v", got)
+	}
+}
+
+func TestStreamKeepsApplyOrder(t *testing.T) {
+	manifests := generate(t, filepath.Join("testdata", "full.yaml"))
+	stream := Stream(manifests)
+
+	var want []string
+	for _, name := range sortedKeys(manifests) {
+		want = append(want, strings.Split(manifests[name], "\n---\n")...)
+	}
+	if got := strings.Split(stream, "\n---\n"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("le flux ne suit pas l'ordre des fichiers:\n%s", stream)
+	}
+	if !strings.Contains(want[0], "kind: Namespace") {
+		t.Errorf("le flux doit commencer par le namespace, obtenu:\n%s", want[0])
+	}
+}
+
+// La liste JSON doit contenir les mêmes objets, dans le même ordre, que la
+// sortie YAML.
+func TestGenerateJSONMatchesManifests(t *testing.T) {
+	for _, configFile := range goldenCases(t, "testdata") {
+		files, err := New(loadConfig(t, configFile)).GenerateJSON()
+		if err != nil {
+			t.Fatalf("génération de %s: %v", configFile, err)
+		}
+
+		var got struct {
+			Kind  string `json:"kind"`
+			Items []any  `json:"items"`
+		}
+		if err := json.Unmarshal([]byte(files["teleflix"]), &got); err != nil {
+			t.Fatalf("%s: JSON invalide: %v", configFile, err)
+		}
+
+		// Normaliser les objets YAML en passant par JSON (entiers en float64)
+		data, err := json.Marshal(decodeStream(t, generate(t, configFile)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []any
+		if err := json.Unmarshal(data, &want); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Kind != "List" || !reflect.DeepEqual(got.Items, want) {
+			t.Errorf("%s: la liste JSON diffère des manifests YAML", configFile)
+		}
+	}
+}
+
+func TestGenerateAllDeterministic(t *testing.T) {
+	for _, configFile := range goldenCases(t, "testdata") {
+		first := generate(t, configFile)
+		for i := 0; i < 20; i++ {
+			if next := generate(t, configFile); !reflect.DeepEqual(first, next) {
+				t.Fatalf("%s: la sortie change entre deux générations", configFile)
+			}
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"teleflix/internal/config"
+	"teleflix/internal/k8s"
+
+	"gopkg.in/yaml.v3"
+)
+
+type helmChart struct {
+	APIVersion  string `yaml:"apiVersion"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Type        string `yaml:"type"`
+	Version     string `yaml:"version"`
+	AppVersion  string `yaml:"appVersion"`
+}
+
+// helmValues est le values.yaml du chart, dérivé de config.Config.
+type helmValues struct {
+	Namespace    string `yaml:"namespace"`
+	Domain       string `yaml:"domain"`
+	StorageClass string `yaml:"storageClass"`
+	Storage      struct {
+		Media     *helmVolumeValues `yaml:"media,omitempty"`
+		Downloads *helmVolumeValues `yaml:"downloads,omitempty"`
+		Data      *helmVolumeValues `yaml:"data,omitempty"` // Disposition unified
+	} `yaml:"storage"`
+	Ingress struct {
+		Enabled bool `yaml:"enabled"`
+		TLS     struct {
+			SecretName string `yaml:"secretName"`
+		} `yaml:"tls"`
+	} `yaml:"ingress"`
+	Services map[string]helmServiceValues `yaml:"services"`
+}
+
+type helmVolumeValues struct {
+	Size string `yaml:"size"`
+}
+
+type helmServiceValues struct {
+	Enabled     bool                   `yaml:"enabled"`
+	Exposed     bool                   `yaml:"exposed"`
+	Image       string                 `yaml:"image"`
+	Tag         string                 `yaml:"tag"`
+	Resources   config.ResourcesConfig `yaml:"resources"`
+	Environment map[string]string      `yaml:"environment,omitempty"`
+}
+
+// helmTemplater remplace des valeurs de la configuration par des jetons
+// uniques, substitués après sérialisation par des expressions Helm.
+type helmTemplater struct {
+	replacements []string
+}
+
+func (h *helmTemplater) token(expr string) string {
+	tok := fmt.Sprintf("__teleflix_helm_%d__", len(h.replacements)/2)
+	h.replacements = append(h.replacements, tok, "{{ "+expr+" }}")
+	return tok
+}
+
+func (h *helmTemplater) apply(content string) string {
+	return strings.NewReplacer(h.replacements...).Replace(content)
+}
+
+// GenerateHelmChart produit un chart Helm complet : Chart.yaml, values.yaml
+// et un template par fichier de manifests. Tous les services du catalogue
+// sont inclus, activés ou non selon values.yaml, afin de pouvoir les
+// surcharger avec helm upgrade sans relancer teleflix.
+func (g *Generator) GenerateHelmChart() (map[string]string, error) {
+	values := g.helmValues()
+	tmpl := &helmTemplater{}
+	tcfg, err := g.templatedConfig(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	tgen := New(tcfg)
+
+	manifests, err := tgen.buildManifests()
+	if err != nil {
+		return nil, err
+	}
+	// Seuls les services activés à la génération sont soumis au niveau
+	// enforce : les autres ne sont déployés que si values.yaml les active
+	var enabled []Manifest
+	for _, m := range manifests {
+		if m.Service == "" || g.config.Services[m.Service].Enabled {
+			enabled = append(enabled, m)
+		}
+	}
+	if err := tgen.enforcePodSecurity(enabled); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	for _, m := range manifests {
+		var content string
+		switch {
+		case m.Name == "99-ingress":
+			content, err = tgen.helmIngress(m.Objects[0].(*k8s.Ingress))
+		case m.Name == "02-cert-manager":
+			content, err = tgen.helmCertManager(m.Objects)
+		case m.Service != "":
+			content, err = tgen.helmServiceManifest(m)
+		default:
+			content, err = renderYAML(m.Objects)
+			content = helmEscape(content)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		files["templates/"+m.Name] = tmpl.apply(content)
+	}
+
+	chart, err := yaml.Marshal(&helmChart{
+		APIVersion:  "v2",
+		Name:        "teleflix",
+		Description: "Stack de streaming générée par teleflix",
+		Type:        "application",
+		Version:     "0.1.0",
+		AppVersion:  "latest",
+	})
+	if err != nil {
+		return nil, err
+	}
+	valuesData, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	files["Chart"] = string(chart)
+	files["values"] = string(valuesData)
+	return files, nil
+}
+
+func (g *Generator) helmValues() *helmValues {
+	values := &helmValues{
+		Namespace:    g.config.Namespace,
+		Domain:       g.config.Domain,
+		StorageClass: g.config.StorageClass,
+		Services:     make(map[string]helmServiceValues),
+	}
+	if g.unifiedLayout() {
+		values.Storage.Data = &helmVolumeValues{Size: g.config.Storage.Data.Size}
+	} else {
+		values.Storage.Media = &helmVolumeValues{Size: g.config.Storage.Media.Size}
+		values.Storage.Downloads = &helmVolumeValues{Size: g.config.Storage.Downloads.Size}
+	}
+	values.Ingress.Enabled = g.config.Ingress.Enabled
+	values.Ingress.TLS.SecretName = g.config.Ingress.TLS.SecretName
+
+	for _, svc := range g.services() {
+		values.Services[svc.name] = helmServiceValues{
+			Enabled:     svc.config.Enabled,
+			Exposed:     svc.config.Exposed,
+			Image:       svc.config.Image,
+			Tag:         svc.config.Tag,
+			Resources:   svc.config.Resources,
+			Environment: svc.config.Environment,
+		}
+	}
+	return values
+}
+
+// templatedConfig retourne une copie de la configuration où chaque valeur
+// surchargeable est remplacée par un jeton. Tous les services y sont activés
+// et exposés : l'activation réelle est décidée par values.yaml.
+func (g *Generator) templatedConfig(tmpl *helmTemplater) (*config.Config, error) {
+	tcfg := *g.config
+	tcfg.Namespace = tmpl.token(".Values.namespace")
+	tcfg.Domain = tmpl.token(".Values.domain")
+	tcfg.StorageClass = tmpl.token(".Values.storageClass")
+	tcfg.Storage.Media.Size = tmpl.token(".Values.storage.media.size | quote")
+	tcfg.Storage.Downloads.Size = tmpl.token(".Values.storage.downloads.size | quote")
+	tcfg.Storage.Data.Size = tmpl.token(".Values.storage.data.size | quote")
+	tcfg.Ingress.TLS.SecretName = tmpl.token(".Values.ingress.tls.secretName")
+
+	resolvedConfig := *g.config
+	resolvedConfig.Namespace = tcfg.Namespace
+	resolved := New(&resolvedConfig)
+
+	tcfg.Services = make(config.ServiceCatalog, len(g.config.Services))
+	for name, svc := range g.config.Services {
+		values := helmService(name)
+		svc.Enabled = true
+		svc.Exposed = true
+		svc.Image = tmpl.token(values + ".image")
+		svc.Tag = tmpl.token(values + ".tag")
+
+		resources := fmt.Sprintf("index .Values.services %q \"resources\"", name)
+		svc.Resources.Requests.CPU = tmpl.token(resources + ` "requests" "cpu" | quote`)
+		svc.Resources.Requests.Memory = tmpl.token(resources + ` "requests" "memory" | quote`)
+		svc.Resources.Limits.CPU = tmpl.token(resources + ` "limits" "cpu" | quote`)
+		svc.Resources.Limits.Memory = tmpl.token(resources + ` "limits" "memory" | quote`)
+
+		// L'identité du pod est fixée à la génération, avant que PUID/PGID
+		// ne deviennent des jetons
+		if svc.Security.RunAsUser == nil {
+			svc.Security.RunAsUser = parseID(svc.Environment["PUID"])
+		}
+		if svc.Security.RunAsGroup == nil {
+			svc.Security.RunAsGroup = parseID(svc.Environment["PGID"])
+		}
+
+		env := make(map[string]string, len(svc.Environment))
+		for key := range svc.Environment {
+			env[key] = tmpl.token(fmt.Sprintf("index .Values.services %q \"environment\" %q | quote", name, key))
+		}
+		svc.Environment = env
+
+		// Les liens sont résolus à la génération : un service activé
+		// ultérieurement via values.yaml n'ajoute pas sa variable ni ses
+		// flux réseau
+		var links []config.LinkConfig
+		for _, link := range svc.Links {
+			if target, ok := g.config.Services[link.Service]; ok && target.Enabled && g.config.Services[name].Enabled {
+				links = append(links, link)
+			}
+		}
+		svc.Links = links
+
+		// De même, le fichier initial ne décrit que les services activés à
+		// la génération ; seul le namespace reste surchargeable
+		if svc.Seed != nil {
+			content, err := resolved.renderSeed(svc.Seed)
+			if err != nil {
+				return nil, fmt.Errorf("services.%s.seed: %w", name, err)
+			}
+			seed := *svc.Seed
+			seed.Content = content
+			svc.Seed = &seed
+		}
+
+		tcfg.Services[name] = svc
+	}
+	return &tcfg, nil
+}
+
+// helmServiceManifest conditionne le fichier d'un service à son activation,
+// et sa NetworkPolicy d'ingress à son exposition.
+func (g *Generator) helmServiceManifest(m Manifest) (string, error) {
+	objects := m.Objects
+	var fromIngress *k8s.NetworkPolicy
+	if last, ok := objects[len(objects)-1].(*k8s.NetworkPolicy); ok && last.Name == m.Service+"-from-ingress" {
+		fromIngress = last
+		objects = objects[:len(objects)-1]
+	}
+
+	content, err := renderYAML(objects)
+	if err != nil {
+		return "", err
+	}
+	content = helmEscape(content)
+	if fromIngress != nil {
+		policy, err := yaml.Marshal(fromIngress)
+		if err != nil {
+			return "", err
+		}
+		content += fmt.Sprintf("{{- if and .Values.ingress.enabled (%s) }}\n---\n%s{{- end }}\n", helmServiceExposed(m.Service), helmEscape(string(policy)))
+	}
+	return fmt.Sprintf("{{- if %s.enabled }}\n%s{{- end }}\n", helmService(m.Service), content), nil
+}
+
+// helmEscape protège les accolades présentes dans les valeurs (ex: {{PORTS}}
+// de gluetun) afin que Helm les recopie telles quelles.
+func helmEscape(content string) string {
+	return strings.ReplaceAll(content, "{{", `{{ "{{" }}`)
+}
+
+// helmService retourne l'expression désignant les values d'un service ;
+// index permet les noms contenant un tiret (ex: jellyfin-4k).
+func helmService(name string) string {
+	return fmt.Sprintf("(index .Values.services %q)", name)
+}
+
+func helmServiceExposed(name string) string {
+	return fmt.Sprintf("and %s.enabled %s.exposed", helmService(name), helmService(name))
+}
+
+// helmAnyExposed définit $exposed, vrai si au moins un service est exposé.
+const helmAnyExposed = `{{- $exposed := false }}
+{{- range $name, $svc := .Values.services }}
+{{- if and $svc.enabled $svc.exposed }}{{ $exposed = true }}{{ end }}
+{{- end }}
+`
+
+// helmIngress construit le template de l'ingress : chaque hôte n'est
+// rendu que si le service correspondant est activé et exposé. Les listes
+// conditionnelles sont écrites explicitement sous spec, dont l'indentation
+// est fixée ici plutôt que par le sérialiseur.
+func (g *Generator) helmIngress(ingress *k8s.Ingress) (string, error) {
+	head, err := marshalWithout(ingress, "spec")
+	if err != nil {
+		return "", err
+	}
+	spec, err := marshalWithout(ingress.Spec, "rules", "tls")
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(helmAnyExposed)
+	b.WriteString("{{- if and .Values.ingress.enabled $exposed }}\n")
+	b.WriteString(helmEscape(head))
+	b.WriteString("spec:\n")
+	b.WriteString(indent(helmEscape(spec), 4))
+
+	rules := ingress.Spec.Rules
+	if tls := ingress.Spec.TLS; len(tls) > 0 {
+		b.WriteString("    tls:\n        - hosts:\n")
+		for _, rule := range rules {
+			name := rule.HTTP.Paths[0].Backend.Service.Name
+			fmt.Fprintf(&b, "{{- if %s }}\n            - %s\n{{- end }}\n", helmServiceExposed(name), helmEscape(rule.Host))
+		}
+		fmt.Fprintf(&b, "          secretName: %s\n", helmEscape(tls[0].SecretName))
+	}
+
+	b.WriteString("    rules:\n")
+	for _, rule := range rules {
+		ruleData, err := yaml.Marshal([]k8s.IngressRule{rule})
+		if err != nil {
+			return "", err
+		}
+		name := rule.HTTP.Paths[0].Backend.Service.Name
+		fmt.Fprintf(&b, "{{- if %s }}\n%s{{- end }}\n", helmServiceExposed(name), indent(helmEscape(string(ruleData)), 8))
+	}
+
+	b.WriteString("{{- end }}\n")
+	return b.String(), nil
+}
+
+// helmCertManager construit le template cert-manager : le Certificate ne
+// couvre que les hôtes des services activés et exposés.
+func (g *Generator) helmCertManager(objects []any) (string, error) {
+	var docs []string
+	for _, obj := range objects {
+		cert, ok := obj.(*k8s.Certificate)
+		if !ok {
+			data, err := yaml.Marshal(obj)
+			if err != nil {
+				return "", err
+			}
+			docs = append(docs, helmEscape(string(data)))
+			continue
+		}
+
+		head, err := marshalWithout(cert, "spec")
+		if err != nil {
+			return "", err
+		}
+		spec, err := marshalWithout(cert.Spec, "dnsNames")
+		if err != nil {
+			return "", err
+		}
+
+		var b strings.Builder
+		b.WriteString(helmAnyExposed)
+		b.WriteString("{{- if $exposed }}\n")
+		b.WriteString(helmEscape(head))
+		b.WriteString("spec:\n")
+		b.WriteString(indent(helmEscape(spec), 4))
+		b.WriteString("    dnsNames:\n")
+		for _, svc := range g.exposedServices() {
+			host := fmt.Sprintf("%s.%s", svc.name, g.config.Domain)
+			if slices.Contains(cert.Spec.DNSNames, host) {
+				fmt.Fprintf(&b, "{{- if %s }}\n        - %s\n{{- end }}\n", helmServiceExposed(svc.name), helmEscape(host))
+			}
+		}
+		b.WriteString("{{- end }}\n")
+		docs = append(docs, b.String())
+	}
+	return strings.Join(docs, "\n---\n"), nil
+}
+
+// marshalWithout sérialise obj sans les clés de premier niveau données,
+// remplacées ensuite par des blocs conditionnels. Retourne une chaîne vide
+// s'il ne reste aucune clé.
+func marshalWithout(obj any, keys ...string) (string, error) {
+	var node yaml.Node
+	if err := node.Encode(obj); err != nil {
+		return "", err
+	}
+	var content []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !slices.Contains(keys, node.Content[i].Value) {
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+	}
+	if len(content) == 0 {
+		return "", nil
+	}
+	node.Content = content
+	data, err := yaml.Marshal(&node)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// indent préfixe chaque ligne non vide de s par n espaces.
+func indent(s string, n int) string {
+	prefix := strings.Repeat(" ", n)
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// helmFuncs reproduit les fonctions sprig utilisées par les templates générés.
+var helmFuncs = template.FuncMap{
+	"quote": func(v any) string { return strconv.Quote(fmt.Sprint(v)) },
+}
+
+// renderChart rend les templates du chart avec son values.yaml, comme le
+// ferait helm template.
+func renderChart(t *testing.T, chart map[string]string) map[string]string {
+	t.Helper()
+
+	var values map[string]any
+	if err := yaml.Unmarshal([]byte(chart["values"]), &values); err != nil {
+		t.Fatalf("values.yaml invalide: %v", err)
+	}
+
+	rendered := make(map[string]string)
+	for name, content := range chart {
+		if !strings.HasPrefix(name, "templates/") {
+			continue
+		}
+		tmpl, err := template.New(name).Funcs(helmFuncs).Option("missingkey=error").Parse(content)
+		if err != nil {
+			t.Fatalf("%s: template invalide: %v", name, err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, map[string]any{"Values": values}); err != nil {
+			t.Fatalf("%s: rendu impossible: %v", name, err)
+		}
+		rendered[strings.TrimPrefix(name, "templates/")] = out.String()
+	}
+	return rendered
+}
+
+// decodeStream décode les fichiers, dans l'ordre de leur nom, en une liste
+// d'objets ; les documents vides (services désactivés) sont ignorés.
+func decodeStream(t *testing.T, files map[string]string) []any {
+	t.Helper()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var objects []any
+	for _, name := range names {
+		decoder := yaml.NewDecoder(strings.NewReader(files[name]))
+		for {
+			var obj any
+			err := decoder.Decode(&obj)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: YAML invalide: %v\n%s", name, err, files[name])
+			}
+			if obj != nil {
+				objects = append(objects, obj)
+			}
+		}
+	}
+	return objects
+}
+
+// Avec son values.yaml par défaut, le chart doit produire exactement les
+// mêmes objets que la sortie Kubernetes classique.
+func TestHelmChartMatchesManifests(t *testing.T) {
+	for _, configFile := range goldenCases(t, "testdata") {
+		name := strings.TrimSuffix(filepath.Base(configFile), ".yaml")
+
+		t.Run(name, func(t *testing.T) {
+			gen := New(loadConfig(t, configFile))
+			chart, err := gen.GenerateHelmChart()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, required := range []string{"Chart", "values"} {
+				if _, ok := chart[required]; !ok {
+					t.Fatalf("%s.yaml absent du chart", required)
+				}
+			}
+
+			want := decodeStream(t, generate(t, configFile))
+			got := decodeStream(t, renderChart(t, chart))
+			if !reflect.DeepEqual(got, want) {
+				gotData, _ := yaml.Marshal(got)
+				wantData, _ := yaml.Marshal(want)
+				t.Errorf("le chart rendu diffère des manifests:\n--- chart ---\n%s\n--- manifests ---\n%s", gotData, wantData)
+			}
+		})
+	}
+}
+
+// Les accolades des valeurs de l'ingress et du Certificate doivent être
+// recopiées telles quelles par Helm.
+func TestHelmChartEscapesIngressValues(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "full.yaml"))
+	cfg.Ingress.Annotations = map[string]string{
+		"nginx.ingress.kubernetes.io/configuration-snippet": `more_set_headers "X-Template: {{ .Values }}";`,
+	}
+	cfg.CertManager.Issuer.Email = "{{admin}}@example.com"
+
+	chart, err := New(cfg).GenerateHelmChart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifests, err := New(cfg).GenerateAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := decodeStream(t, manifests)
+	got := decodeStream(t, renderChart(t, chart))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("le chart rendu diffère des manifests")
+	}
+}
+
+// Un service désactivé n'est déployé que si values.yaml l'active : son pod
+// ne doit pas faire échouer la génération du chart.
+func TestHelmChartSkipsEnforceForDisabledServices(t *testing.T) {
+	cfg := loadConfig(t, filepath.Join("testdata", "vpn.yaml"))
+	cfg.PodSecurity.Enforce = "baseline"
+	qbittorrent := cfg.Services["qbittorrent"]
+	qbittorrent.Enabled = false
+	cfg.Services["qbittorrent"] = qbittorrent
+
+	if _, err := New(cfg).GenerateHelmChart(); err != nil {
+		t.Fatalf("le service désactivé ne devrait pas être vérifié: %v", err)
+	}
+
+	qbittorrent.Enabled = true
+	cfg.Services["qbittorrent"] = qbittorrent
+	if _, err := New(cfg).GenerateHelmChart(); err == nil || !strings.Contains(err.Error(), "Deployment qbittorrent") {
+		t.Fatalf("erreur attendue sur qbittorrent, obtenu: %v", err)
+	}
+}
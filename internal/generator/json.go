@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"encoding/json"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GenerateJSON produit tous les objets Kubernetes dans un unique objet List
+// au format JSON, dans l'ordre des fichiers (comme Stream).
+func (g *Generator) GenerateJSON() (map[string]string, error) {
+	manifests, err := g.Manifests()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return manifests[i].Name < manifests[j].Name
+	})
+
+	items := []any{}
+	for _, m := range manifests {
+		for _, obj := range m.Objects {
+			// Passer par YAML réutilise les tags yaml des types k8s
+			data, err := yaml.Marshal(obj)
+			if err != nil {
+				return nil, err
+			}
+			var item any
+			if err := yaml.Unmarshal(data, &item); err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+	}
+
+	data, err := json.MarshalIndent(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"teleflix": string(data) + "\n"}, nil
+}
@@ -0,0 +1,274 @@
+package generator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"teleflix/internal/config"
+	"teleflix/internal/k8s"
+	"teleflix/internal/kustomize"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overlay décrit un environnement (dev, staging, prod...) dérivé de la base.
+type Overlay struct {
+	Name   string
+	Config *config.Config
+}
+
+// GenerateKustomize produit une base kustomize contenant tous les manifests,
+// puis un overlay par environnement. Chaque overlay ne contient que les
+// différences avec la base : namespace, storageClass, domaine, réplicas et
+// ressources des services.
+func (g *Generator) GenerateKustomize(overlays []Overlay) (map[string]string, error) {
+	manifests, err := g.Manifests()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	base := newKustomization()
+	for _, m := range manifests {
+		content, err := renderYAML(m.Objects)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		files["base/"+m.Name] = content
+		base.Resources = append(base.Resources, m.Name+".yaml")
+	}
+	data, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	files["base/kustomization"] = string(data)
+
+	for _, overlay := range overlays {
+		overlayFiles, err := g.kustomizeOverlay(manifests, overlay.Config)
+		if err != nil {
+			return nil, fmt.Errorf("overlay %s: %w", overlay.Name, err)
+		}
+		for name, content := range overlayFiles {
+			files["overlays/"+overlay.Name+"/"+name] = content
+		}
+	}
+	return files, nil
+}
+
+func newKustomization() *kustomize.Kustomization {
+	return &kustomize.Kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+	}
+}
+
+// kustomizeOverlay compare les objets de la base à ceux générés avec la
+// configuration de l'overlay, objet par objet, et en déduit les patchs.
+func (g *Generator) kustomizeOverlay(base []Manifest, cfg *config.Config) (map[string]string, error) {
+	if err := g.checkOverlay(cfg); err != nil {
+		return nil, err
+	}
+	manifests, err := New(cfg).Manifests()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	k := newKustomization()
+	k.Resources = []string{"../../base"}
+	if cfg.Namespace != g.config.Namespace {
+		k.Namespace = cfg.Namespace
+	}
+
+	// checkOverlay garantit que les deux listes ont la même structure
+	for i, m := range base {
+		for j, obj := range m.Objects {
+			other := manifests[i].Objects[j]
+
+			// Le namespace de kustomize n'atteint pas le claimRef des PV statiques
+			if pv, ok := obj.(*k8s.PersistentVolume); ok && k.Namespace != "" {
+				return nil, fmt.Errorf("%s: le PersistentVolume statique %s est lié au namespace de la base, l'overlay ne peut pas le changer", m.Name, pv.Name)
+			}
+
+			if deployment, ok := obj.(*k8s.Deployment); ok {
+				replicas := *other.(*k8s.Deployment).Spec.Replicas
+				if replicas != *deployment.Spec.Replicas {
+					k.Replicas = append(k.Replicas, kustomize.Replica{Name: deployment.Name, Count: replicas})
+				}
+			}
+
+			name, patch := kustomizePatch(obj, other)
+			if patch == nil {
+				continue
+			}
+			data, err := yaml.Marshal(patch)
+			if err != nil {
+				return nil, err
+			}
+			files["patches/"+name] = string(data)
+			k.Patches = append(k.Patches, kustomize.Patch{Path: "patches/" + name + ".yaml"})
+		}
+	}
+
+	data, err := yaml.Marshal(k)
+	if err != nil {
+		return nil, err
+	}
+	files["kustomization"] = string(data)
+	return files, nil
+}
+
+// checkOverlay vérifie que l'overlay ne diffère de la base que par des champs
+// exprimables en patch : on neutralise ces champs puis on compare la sortie.
+func (g *Generator) checkOverlay(cfg *config.Config) error {
+	normalized := *cfg
+	normalized.Namespace = g.config.Namespace
+	normalized.StorageClass = g.config.StorageClass
+	normalized.Domain = g.config.Domain
+	normalized.Services = make(config.ServiceCatalog, len(cfg.Services))
+	for name, svc := range cfg.Services {
+		if base, ok := g.config.Services[name]; ok {
+			svc.Replicas = base.Replicas
+			svc.Resources = base.Resources
+		}
+		normalized.Services[name] = svc
+	}
+
+	want, err := g.GenerateAll()
+	if err != nil {
+		return err
+	}
+	got, err := New(&normalized).GenerateAll()
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	for name, content := range want {
+		if got[name] != content {
+			changed = append(changed, name)
+		}
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return fmt.Errorf("seuls namespace, storageClass, domain et les replicas/resources des services peuvent différer de la base (fichiers concernés: %s)",
+			strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// kustomizePatch retourne le nom et le contenu du patch strategic merge
+// transformant base en overlay, ou nil si aucun champ patchable ne change.
+func kustomizePatch(base, overlay any) (string, map[string]any) {
+	var spec map[string]any
+	var data map[string]string
+	var typeMeta k8s.TypeMeta
+	var meta k8s.ObjectMeta
+
+	switch b := base.(type) {
+	case *k8s.PersistentVolumeClaim:
+		o := overlay.(*k8s.PersistentVolumeClaim)
+		typeMeta, meta = b.TypeMeta, b.ObjectMeta
+		if *o.Spec.StorageClassName != *b.Spec.StorageClassName {
+			spec = map[string]any{"storageClassName": *o.Spec.StorageClassName}
+		}
+
+	case *k8s.Deployment:
+		o := overlay.(*k8s.Deployment)
+		typeMeta, meta = b.TypeMeta, b.ObjectMeta
+		if container := containerPatch(b.Spec.Template.Spec.Containers[0], o.Spec.Template.Spec.Containers[0]); container != nil {
+			spec = map[string]any{
+				"template": map[string]any{
+					"spec": map[string]any{
+						"containers": []any{container},
+					},
+				},
+			}
+		}
+
+	case *k8s.Ingress:
+		o := overlay.(*k8s.Ingress)
+		typeMeta, meta = b.TypeMeta, b.ObjectMeta
+		// Les listes rules et tls n'ont pas de clé de fusion : elles sont remplacées
+		if !reflect.DeepEqual(o.Spec.Rules, b.Spec.Rules) || !reflect.DeepEqual(o.Spec.TLS, b.Spec.TLS) {
+			spec = map[string]any{"rules": o.Spec.Rules}
+			if o.Spec.TLS != nil {
+				spec["tls"] = o.Spec.TLS
+			}
+		}
+
+	case *k8s.Certificate:
+		o := overlay.(*k8s.Certificate)
+		typeMeta, meta = b.TypeMeta, b.ObjectMeta
+		if !reflect.DeepEqual(o.Spec.DNSNames, b.Spec.DNSNames) {
+			spec = map[string]any{"dnsNames": o.Spec.DNSNames}
+		}
+
+	case *k8s.ConfigMap:
+		// Le fichier initial contient les adresses des services, qui
+		// dépendent du namespace
+		o := overlay.(*k8s.ConfigMap)
+		typeMeta, meta = b.TypeMeta, b.ObjectMeta
+		if !reflect.DeepEqual(o.Data, b.Data) {
+			data = o.Data
+		}
+	}
+
+	if spec == nil && data == nil {
+		return "", nil
+	}
+
+	// Les patchs sont appliqués avant le changement de namespace : ils
+	// ciblent donc les objets de la base
+	metadata := map[string]any{"name": meta.Name}
+	if meta.Namespace != "" {
+		metadata["namespace"] = meta.Namespace
+	}
+	name := fmt.Sprintf("%s-%s", strings.ToLower(typeMeta.Kind), meta.Name)
+	patch := map[string]any{
+		"apiVersion": typeMeta.APIVersion,
+		"kind":       typeMeta.Kind,
+		"metadata":   metadata,
+	}
+	if data != nil {
+		patch["data"] = data
+	} else {
+		patch["spec"] = spec
+	}
+	return name, patch
+}
+
+// containerPatch retourne les variables d'environnement modifiées (URL des
+// liens lorsque le namespace change) et les nouvelles ressources.
+func containerPatch(base, overlay k8s.Container) map[string]any {
+	values := make(map[string]string, len(base.Env))
+	for _, env := range base.Env {
+		values[env.Name] = env.Value
+	}
+
+	var env []k8s.EnvVar
+	for _, e := range overlay.Env {
+		if values[e.Name] != e.Value {
+			env = append(env, e)
+		}
+	}
+
+	patch := map[string]any{"name": overlay.Name}
+	if len(env) > 0 {
+		patch["env"] = env
+	}
+	if !reflect.DeepEqual(overlay.Resources, base.Resources) {
+		patch["resources"] = overlay.Resources
+	}
+	if len(patch) == 1 {
+		return nil
+	}
+	return patch
+}
@@ -0,0 +1,197 @@
+package generator
+
+import (
+	"slices"
+
+	"teleflix/internal/config"
+	"teleflix/internal/k8s"
+)
+
+// Les plages privées sont exclues de l'accès Internet : un service
+// n'atteint les autres pods du cluster que par ses liens.
+var (
+	privateIPv4 = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+	privateIPv6 = []string{"fc00::/7"}
+)
+
+// CoreDNS porte le label historique de kube-dns, dans kube-system.
+var (
+	defaultDNSNamespace = "kube-system"
+	defaultDNSSelector  = map[string]string{"k8s-app": "kube-dns"}
+)
+
+// namespaceLabel est posé par Kubernetes sur chaque namespace (1.21+).
+const namespaceLabel = "kubernetes.io/metadata.name"
+
+func (g *Generator) newNetworkPolicy(name string, spec k8s.NetworkPolicySpec) *k8s.NetworkPolicy {
+	return &k8s.NetworkPolicy{
+		TypeMeta: k8s.TypeMeta{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "NetworkPolicy",
+		},
+		ObjectMeta: k8s.ObjectMeta{
+			Name:      name,
+			Namespace: g.config.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// generateBaseNetworkPolicies refuse tout trafic par défaut, sauf les
+// requêtes DNS vers CoreDNS (ou les pods de networkPolicy.dnsSelector) et,
+// avec Let's Encrypt, l'accès du contrôleur d'ingress aux solveurs HTTP-01
+// de cert-manager.
+func (g *Generator) generateBaseNetworkPolicies() []any {
+	np := g.config.NetworkPolicy
+	dnsNamespace, dnsSelector := np.DNSNamespace, np.DNSSelector
+	if dnsNamespace == "" {
+		dnsNamespace = defaultDNSNamespace
+	}
+	if len(dnsSelector) == 0 {
+		dnsSelector = defaultDNSSelector
+	}
+
+	policies := []any{
+		g.newNetworkPolicy("default-deny", k8s.NetworkPolicySpec{
+			PolicyTypes: []string{"Ingress", "Egress"},
+		}),
+		g.newNetworkPolicy("allow-dns", k8s.NetworkPolicySpec{
+			PolicyTypes: []string{"Egress"},
+			Egress: []k8s.NetworkPolicyEgressRule{{
+				To: []k8s.NetworkPolicyPeer{{
+					NamespaceSelector: &k8s.LabelSelector{MatchLabels: map[string]string{namespaceLabel: dnsNamespace}},
+					PodSelector:       &k8s.LabelSelector{MatchLabels: dnsSelector},
+				}},
+				Ports: []k8s.NetworkPolicyPort{
+					{Protocol: "UDP", Port: int32Ptr(53)},
+					{Protocol: "TCP", Port: int32Ptr(53)},
+				},
+			}},
+		}),
+	}
+
+	if g.config.CertManager.Enabled && g.config.CertManager.Issuer.Type == "letsencrypt" {
+		policies = append(policies, g.newNetworkPolicy("allow-acme-solver", k8s.NetworkPolicySpec{
+			PodSelector: k8s.LabelSelector{MatchLabels: map[string]string{"acme.cert-manager.io/http01-solver": "true"}},
+			PolicyTypes: []string{"Ingress"},
+			Ingress: []k8s.NetworkPolicyIngressRule{{
+				From:  []k8s.NetworkPolicyPeer{g.ingressControllerPeer()},
+				Ports: []k8s.NetworkPolicyPort{{Protocol: "TCP", Port: int32Ptr(8089)}},
+			}},
+		}))
+	}
+	return policies
+}
+
+// serviceNetworkPolicy autorise les flux déclarés par les liens, sur tous
+// les ports du service lié : entrée depuis les services qui dépendent de
+// celui-ci, sortie vers ses propres dépendances, et Internet si
+// internetAccess ou le VPN est activé. Les ports publiés (NodePort,
+// LoadBalancer) acceptent toute source.
+func (g *Generator) serviceNetworkPolicy(name string, cfg config.ServiceConfig) *k8s.NetworkPolicy {
+	spec := k8s.NetworkPolicySpec{
+		PodSelector: k8s.LabelSelector{MatchLabels: map[string]string{"app": name}},
+		PolicyTypes: []string{"Ingress", "Egress"},
+	}
+
+	var sources []k8s.NetworkPolicyPeer
+	for _, svc := range g.services() {
+		if svc.name != name && svc.config.Enabled && linksTo(svc.config, name) {
+			sources = append(sources, appPeer(svc.name))
+		}
+	}
+	if len(sources) > 0 {
+		spec.Ingress = append(spec.Ingress, k8s.NetworkPolicyIngressRule{
+			From:  sources,
+			Ports: linkPorts(cfg),
+		})
+	}
+
+	// Les ports NodePort et LoadBalancer sont joignables depuis l'extérieur
+	var external []k8s.NetworkPolicyPort
+	if cfg.Service.Type == "NodePort" || cfg.Service.Type == "LoadBalancer" {
+		external = append(external, k8s.NetworkPolicyPort{Protocol: "TCP", Port: int32Ptr(cfg.Port)})
+	}
+	for _, t := range externalServiceTypes {
+		for _, p := range externalPorts(cfg, t.serviceType) {
+			external = append(external, k8s.NetworkPolicyPort{Protocol: portProtocol(p), Port: int32Ptr(p.Port)})
+		}
+	}
+	if len(external) > 0 {
+		spec.Ingress = append(spec.Ingress, k8s.NetworkPolicyIngressRule{
+			From: []k8s.NetworkPolicyPeer{
+				{IPBlock: &k8s.IPBlock{CIDR: "0.0.0.0/0"}},
+				{IPBlock: &k8s.IPBlock{CIDR: "::/0"}},
+			},
+			Ports: external,
+		})
+	}
+
+	for _, svc := range g.services() {
+		if svc.name != name && svc.config.Enabled && linksTo(cfg, svc.name) {
+			spec.Egress = append(spec.Egress, k8s.NetworkPolicyEgressRule{
+				To:    []k8s.NetworkPolicyPeer{appPeer(svc.name)},
+				Ports: linkPorts(svc.config),
+			})
+		}
+	}
+	// Le sidecar VPN doit joindre les serveurs de son fournisseur
+	if cfg.InternetAccess || cfg.VPN.Enabled {
+		spec.Egress = append(spec.Egress, k8s.NetworkPolicyEgressRule{
+			To: []k8s.NetworkPolicyPeer{
+				{IPBlock: &k8s.IPBlock{CIDR: "0.0.0.0/0", Except: privateIPv4}},
+				{IPBlock: &k8s.IPBlock{CIDR: "::/0", Except: privateIPv6}},
+			},
+		})
+	}
+
+	return g.newNetworkPolicy(name, spec)
+}
+
+// ingressNetworkPolicy ouvre un service exposé au contrôleur d'ingress. Elle
+// est séparée de serviceNetworkPolicy pour que le chart Helm puisse la
+// conditionner à l'exposition du service.
+func (g *Generator) ingressNetworkPolicy(name string, cfg config.ServiceConfig) *k8s.NetworkPolicy {
+	return g.newNetworkPolicy(name+"-from-ingress", k8s.NetworkPolicySpec{
+		PodSelector: k8s.LabelSelector{MatchLabels: map[string]string{"app": name}},
+		PolicyTypes: []string{"Ingress"},
+		Ingress: []k8s.NetworkPolicyIngressRule{{
+			From:  []k8s.NetworkPolicyPeer{g.ingressControllerPeer()},
+			Ports: []k8s.NetworkPolicyPort{{Protocol: "TCP", Port: int32Ptr(cfg.Port)}},
+		}},
+	})
+}
+
+func (g *Generator) ingressControllerPeer() k8s.NetworkPolicyPeer {
+	return k8s.NetworkPolicyPeer{
+		NamespaceSelector: &k8s.LabelSelector{MatchLabels: map[string]string{namespaceLabel: g.config.NetworkPolicy.IngressNamespace}},
+	}
+}
+
+// linkPorts retourne les ports qu'un lien ouvre vers le service : le port
+// principal et chaque port supplémentaire, avec son protocole.
+func linkPorts(cfg config.ServiceConfig) []k8s.NetworkPolicyPort {
+	ports := []k8s.NetworkPolicyPort{{Protocol: "TCP", Port: int32Ptr(cfg.Port)}}
+	for _, p := range extraPorts(cfg) {
+		port := k8s.NetworkPolicyPort{Protocol: portProtocol(p), Port: int32Ptr(p.Port)}
+		if !slices.ContainsFunc(ports, func(q k8s.NetworkPolicyPort) bool {
+			return q.Protocol == port.Protocol && *q.Port == *port.Port
+		}) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+func appPeer(name string) k8s.NetworkPolicyPeer {
+	return k8s.NetworkPolicyPeer{PodSelector: &k8s.LabelSelector{MatchLabels: map[string]string{"app": name}}}
+}
+
+func linksTo(cfg config.ServiceConfig, target string) bool {
+	for _, link := range cfg.Links {
+		if link.Service == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// claimLocation désigne un dossier d'un PVC, relatif à sa racine.
+type claimLocation struct {
+	Claim string
+	Path  string
+}
+
+// downloadsLocation retourne l'emplacement des téléchargements selon la
+// disposition du stockage.
+func (g *Generator) downloadsLocation() claimLocation {
+	if g.unifiedLayout() {
+		return claimLocation{Claim: g.sharedClaim("data"), Path: dataSubPaths["downloads"]}
+	}
+	return claimLocation{Claim: g.sharedClaim("downloads")}
+}
+
+func (g *Generator) mediaLocation() claimLocation {
+	if g.unifiedLayout() {
+		return claimLocation{Claim: g.sharedClaim("data"), Path: dataSubPaths["media"]}
+	}
+	return claimLocation{Claim: g.sharedClaim("media")}
+}
+
+// containerPath retourne le chemin sous lequel un conteneur voit loc, ou
+// false si aucun de ses montages ne le contient.
+func containerPath(mounts []volumeMount, loc claimLocation) (string, bool) {
+	for _, m := range mounts {
+		if m.Claim != loc.Claim {
+			continue
+		}
+		if m.SubPath == "" || loc.Path == m.SubPath || strings.HasPrefix(loc.Path, m.SubPath+"/") {
+			return path.Join(m.MountPath, strings.TrimPrefix(loc.Path, m.SubPath)), true
+		}
+	}
+	return "", false
+}
+
+// pathMappingWarnings compare les chemins des téléchargements entre les
+// services qui montent leur PVC. Le client de téléchargement transmet le
+// chemin de ses fichiers terminés : s'il diffère du chemin vu par le *arr,
+// l'import échoue sans erreur explicite. Les services sont comparés qu'un
+// lien les relie ou non.
+//
+// Un client est un service qui monte les téléchargements et qu'un autre
+// service monté dessus pilote (lien), ou qui ne monte pas les médias ; les
+// services qui déclarent un lien vers lui doivent aussi y accéder.
+func (g *Generator) pathMappingWarnings() []string {
+	downloads, media := g.downloadsLocation(), g.mediaLocation()
+
+	type mounted struct {
+		name, path string
+		media      bool // Monte aussi les médias, comme un *arr
+		client     bool
+	}
+	var services []mounted
+	paths := make(map[string]string)
+	for _, svc := range g.services() {
+		if !svc.config.Enabled {
+			continue
+		}
+		mounts := g.volumeMounts(svc.name, svc.config)
+		if p, ok := containerPath(mounts, downloads); ok {
+			_, withMedia := containerPath(mounts, media)
+			services = append(services, mounted{name: svc.name, path: p, media: withMedia})
+			paths[svc.name] = p
+		}
+	}
+	for i, m := range services {
+		driven := slices.ContainsFunc(services, func(other mounted) bool {
+			return other.name != m.name && linksTo(g.config.Services[other.name], m.name)
+		})
+		services[i].client = driven || !m.media
+	}
+
+	var warnings []string
+	for _, client := range services {
+		if !client.client {
+			continue
+		}
+		for _, svc := range g.services() {
+			if _, ok := paths[svc.name]; ok || !svc.config.Enabled || !linksTo(svc.config, client.name) {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf(
+				"services.%s.volumes: aucun accès aux téléchargements de %s (%s), les imports échoueront",
+				svc.name, client.name, downloads.Claim))
+		}
+	}
+
+	// Les chemins sont comparés à celui du premier client : un écart entre
+	// deux services implique qu'au moins l'un des deux diffère de ce chemin
+	slices.SortStableFunc(services, func(a, b mounted) int {
+		switch {
+		case a.client == b.client:
+			return 0
+		case a.client:
+			return -1
+		default:
+			return 1
+		}
+	})
+	for i := 1; i < len(services); i++ {
+		ref, svc := services[0], services[i]
+		if svc.path != ref.path {
+			warnings = append(warnings, fmt.Sprintf(
+				"services.%s.volumes: %s monté sous %s alors que %s l'utilise sous %s, aligner les mountPath ou déclarer un Remote Path Mapping",
+				svc.name, downloads.Claim, svc.path, ref.name, ref.path))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"teleflix/internal/k8s"
+	"teleflix/internal/podsecurity"
+)
+
+const podSecurityLabel = "pod-security.kubernetes.io/"
+
+// podSecurityLabels traduit la configuration en labels du namespace, lus
+// par le contrôleur d'admission PodSecurity.
+func (g *Generator) podSecurityLabels() map[string]string {
+	pss := g.config.PodSecurity
+	labels := make(map[string]string)
+	for _, mode := range []struct{ name, level string }{
+		{"enforce", pss.Enforce},
+		{"audit", pss.Audit},
+		{"warn", pss.Warn},
+	} {
+		if mode.level == "" {
+			continue
+		}
+		labels[podSecurityLabel+mode.name] = mode.level
+		if pss.Version != "" {
+			labels[podSecurityLabel+mode.name+"-version"] = pss.Version
+		}
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+// checkPodSecurity vérifie les pods générés au niveau donné et retourne une
+// ligne par champ refusé, préfixée par le Deployment concerné.
+func checkPodSecurity(manifests []Manifest, level string) []string {
+	var problems []string
+	for _, m := range manifests {
+		for _, obj := range m.Objects {
+			deployment, ok := obj.(*k8s.Deployment)
+			if !ok {
+				continue
+			}
+			for _, v := range podsecurity.Check(level, &deployment.Spec.Template.Spec) {
+				problems = append(problems, fmt.Sprintf("Deployment %s: %s", deployment.Name, v))
+			}
+		}
+	}
+	return problems
+}
+
+// enforcePodSecurity refuse la génération si un pod serait rejeté à
+// l'admission par le niveau enforce du namespace.
+func (g *Generator) enforcePodSecurity(manifests []Manifest) error {
+	level := g.config.PodSecurity.Enforce
+	problems := checkPodSecurity(manifests, level)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("des pods seraient refusés par le niveau %q imposé au namespace (podSecurity.enforce):\n  %s",
+		level, strings.Join(problems, "\n  "))
+}
+
+// podSecurityWarnings retourne les champs des pods refusés par les niveaux
+// audit et warn du namespace.
+func (g *Generator) podSecurityWarnings(manifests []Manifest) []string {
+	pss := g.config.PodSecurity
+	var warnings []string
+	checked := map[string]bool{pss.Enforce: true} // Déjà garanti par Manifests
+	for _, mode := range []struct{ name, level string }{
+		{"warn", pss.Warn},
+		{"audit", pss.Audit},
+	} {
+		if checked[mode.level] {
+			continue
+		}
+		checked[mode.level] = true
+		for _, problem := range checkPodSecurity(manifests, mode.level) {
+			warnings = append(warnings, fmt.Sprintf("%s (niveau %s, podSecurity.%s)", problem, mode.level, mode.name))
+		}
+	}
+	return warnings
+}
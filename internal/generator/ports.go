@@ -0,0 +1,161 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+
+	"teleflix/internal/config"
+	"teleflix/internal/k8s"
+)
+
+// Suffixe du Service regroupant les ports d'un type donné, dans l'ordre de
+// génération.
+var externalServiceTypes = []struct{ serviceType, suffix string }{
+	{"NodePort", "-nodeport"},
+	{"LoadBalancer", "-lb"},
+}
+
+func portProtocol(p config.PortConfig) string {
+	if p.Protocol == "" {
+		return "TCP"
+	}
+	return p.Protocol
+}
+
+// extraPorts retourne les ports supplémentaires du service. Avec un port
+// redirigé fixe (vpn.forwardedPort), les ports publiés (serviceType) ne sont
+// joignables que par ce port du tunnel : le conteneur doit écouter dessus, et
+// leur variable (TORRENTING_PORT...) le reporte dans l'application.
+func extraPorts(cfg config.ServiceConfig) []config.PortConfig {
+	if !cfg.VPN.Enabled || cfg.VPN.ForwardedPort == 0 {
+		return cfg.Ports
+	}
+	ports := make([]config.PortConfig, len(cfg.Ports))
+	for i, p := range cfg.Ports {
+		if p.ServiceType != "" {
+			p.Port = cfg.VPN.ForwardedPort
+		}
+		ports[i] = p
+	}
+	return ports
+}
+
+// containerPorts déclare le port principal puis les ports supplémentaires.
+func containerPorts(cfg config.ServiceConfig) []k8s.ContainerPort {
+	ports := []k8s.ContainerPort{{ContainerPort: cfg.Port, Protocol: "TCP"}}
+	for _, p := range extraPorts(cfg) {
+		ports = append(ports, k8s.ContainerPort{Name: p.Name, ContainerPort: p.Port, Protocol: portProtocol(p)})
+	}
+	return ports
+}
+
+// mainServicePorts retourne les ports du Service principal. Kubernetes
+// exige un nom pour chaque port dès qu'il y en a plusieurs.
+func mainServicePorts(cfg config.ServiceConfig) []k8s.ServicePort {
+	ports := []k8s.ServicePort{{Port: cfg.Port, TargetPort: cfg.Port, Protocol: "TCP"}}
+	for _, p := range extraPorts(cfg) {
+		if p.ServiceType == "" {
+			ports = append(ports, k8s.ServicePort{Name: p.Name, Port: p.Port, TargetPort: p.Port, Protocol: portProtocol(p)})
+		}
+	}
+	if len(ports) > 1 {
+		ports[0].Name = config.MainPortName
+	}
+	return ports
+}
+
+// externalPorts retourne les ports publiés hors du cluster. Derrière un
+// VPN, ils ne sont joignables que par le tunnel : aucun Service n'est créé.
+func externalPorts(cfg config.ServiceConfig, serviceType string) []config.PortConfig {
+	if cfg.VPN.Enabled {
+		return nil
+	}
+	var ports []config.PortConfig
+	for _, p := range extraPorts(cfg) {
+		if p.ServiceType == serviceType {
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
+
+// createExternalServices regroupe les ports NodePort et LoadBalancer dans un
+// Service par type, ex: qbittorrent-lb pour le port pair BitTorrent.
+func (g *Generator) createExternalServices(name string, cfg config.ServiceConfig) []any {
+	labels := map[string]string{
+		"app":       name,
+		"component": "teleflix",
+	}
+
+	var services []any
+	for _, t := range externalServiceTypes {
+		var ports []k8s.ServicePort
+		for _, p := range externalPorts(cfg, t.serviceType) {
+			ports = append(ports, k8s.ServicePort{
+				Name:       p.Name,
+				Port:       p.Port,
+				TargetPort: p.Port,
+				Protocol:   portProtocol(p),
+				NodePort:   p.NodePort,
+			})
+		}
+		if len(ports) == 0 {
+			continue
+		}
+
+		services = append(services, &k8s.Service{
+			TypeMeta: k8s.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Service",
+			},
+			ObjectMeta: k8s.ObjectMeta{
+				Name:      name + t.suffix,
+				Namespace: g.config.Namespace,
+				Labels:    labels,
+			},
+			Spec: k8s.ServiceSpec{
+				Type:     t.serviceType,
+				Selector: labels,
+				Ports:    ports,
+			},
+		})
+	}
+	return services
+}
+
+// portEnvironment retourne le numéro des ports déclarant une variable
+// d'environnement, ex: TORRENTING_PORT pour qBittorrent.
+func portEnvironment(cfg config.ServiceConfig) map[string]string {
+	env := make(map[string]string)
+	for _, p := range extraPorts(cfg) {
+		if p.Env != "" {
+			env[p.Env] = strconv.Itoa(int(p.Port))
+		}
+	}
+	return env
+}
+
+// portWarnings signale les ports publiés ignorés à cause du VPN.
+func (g *Generator) portWarnings() []string {
+	var warnings []string
+	for _, svc := range g.services() {
+		if !svc.config.Enabled || !svc.config.VPN.Enabled {
+			continue
+		}
+		for i, p := range svc.config.Ports {
+			if p.ServiceType == "" {
+				continue
+			}
+			if forwarded := svc.config.VPN.ForwardedPort; forwarded != 0 {
+				warnings = append(warnings, fmt.Sprintf(
+					"services.%s.ports[%d]: serviceType %s ignoré, le port %d est remplacé par le port redirigé du tunnel VPN (%d)",
+					svc.name, i, p.ServiceType, p.Port, forwarded))
+			} else {
+				warnings = append(warnings, fmt.Sprintf(
+					"services.%s.ports[%d]: serviceType %s ignoré, le port n'est joignable que par le tunnel VPN (voir vpn.forwardedPort ou vpn.portForwarding)",
+					svc.name, i, p.ServiceType))
+			}
+		}
+	}
+	return warnings
+}
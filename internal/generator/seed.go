@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"teleflix/internal/config"
+	"teleflix/internal/k8s"
+)
+
+const seedImage = "busybox:1.36"
+
+// seedScript copie le fichier initial ($1) vers sa destination ($0)
+// seulement s'il n'existe pas encore : les réglages faits dans
+// l'application ne sont jamais écrasés.
+const seedScript = `test -e "$0" || { mkdir -p "$(dirname "$0")" && cp "$1" "$0"; }`
+
+// renderSeed exécute le template du fichier initial ; les services sont
+// désignés par leur adresse interne au cluster.
+func (g *Generator) renderSeed(seed *config.SeedConfig) (string, error) {
+	funcs := template.FuncMap{
+		"enabled": func(name string) bool {
+			svc, ok := g.config.Services[name]
+			return ok && svc.Enabled
+		},
+		"host": func(name string) string { return fmt.Sprintf("%s.%s.svc", name, g.config.Namespace) },
+		"port": func(name string) int32 { return g.config.Services[name].Port },
+		"url":  func(name string) string { return g.internalURL(name, g.config.Services[name]) },
+	}
+	tmpl, err := template.New(seed.File).Funcs(funcs).Parse(seed.Content)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, nil); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// seedConfigMap porte le fichier initial du service.
+func (g *Generator) seedConfigMap(name string, cfg config.ServiceConfig) (*k8s.ConfigMap, error) {
+	content, err := g.renderSeed(cfg.Seed)
+	if err != nil {
+		return nil, fmt.Errorf("services.%s.seed: %w", name, err)
+	}
+	return &k8s.ConfigMap{
+		TypeMeta: k8s.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: k8s.ObjectMeta{
+			Name:      name + "-seed",
+			Namespace: g.config.Namespace,
+			Labels: map[string]string{
+				"app":       name,
+				"component": "teleflix",
+			},
+		},
+		Data: map[string]string{path.Base(cfg.Seed.File): content},
+	}, nil
+}
+
+// seedContainer copie le fichier initial dans le volume du service avant
+// son démarrage. Il monte ce volume comme le conteneur principal (mounts).
+func seedContainer(name string, cfg config.ServiceConfig, mounts []k8s.VolumeMount) (k8s.Container, k8s.Volume) {
+	seed := cfg.Seed
+	var target k8s.VolumeMount
+	for _, vol := range cfg.Volumes {
+		if vol.Name != seed.Volume {
+			continue
+		}
+		for _, m := range mounts {
+			if m.MountPath == vol.MountPath {
+				target = k8s.VolumeMount{Name: m.Name, MountPath: m.MountPath, SubPath: m.SubPath}
+			}
+		}
+	}
+
+	key := path.Base(seed.File)
+	container := k8s.Container{
+		Name:    "seed",
+		Image:   seedImage,
+		Command: []string{"sh", "-c", seedScript, path.Join(target.MountPath, seed.File), "/seed/" + key},
+		VolumeMounts: []k8s.VolumeMount{
+			target,
+			{Name: "seed", MountPath: "/seed", ReadOnly: true},
+		},
+		Resources: k8s.ResourceRequirements{
+			Requests: map[string]string{"cpu": "10m", "memory": "16Mi"},
+			Limits:   map[string]string{"cpu": "100m", "memory": "64Mi"},
+		},
+		SecurityContext: containerSecurityContext(cfg.Security),
+	}
+	volume := k8s.Volume{
+		Name:         "seed",
+		VolumeSource: k8s.VolumeSource{ConfigMap: &k8s.ConfigMapVolumeSource{Name: name + "-seed"}},
+	}
+	return container, volume
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"teleflix/internal/config"
+	"teleflix/internal/k8s"
+)
+
+// exclusiveModes attachent un volume à un seul nœud, voire à un seul pod
+// pour ReadWriteOncePod.
+var exclusiveModes = []string{"ReadWriteOnce", "ReadWriteOncePod"}
+
+// exclusiveClaims retourne les PVC générés montés par le service en accès
+// exclusif. Le mode d'un PVC existant est inconnu : il n'est pas compté.
+func (g *Generator) exclusiveClaims(name string, cfg config.ServiceConfig) []string {
+	var claims []string
+	for _, m := range g.volumeMounts(name, cfg) {
+		if slices.ContainsFunc(m.AccessModes, func(mode string) bool { return slices.Contains(exclusiveModes, mode) }) {
+			claims = append(claims, m.Claim)
+		}
+	}
+	return claims
+}
+
+// deploymentStrategy choisit Recreate pour un service monté sur un volume
+// exclusif : RollingUpdate démarrerait le nouveau pod avant d'arrêter
+// l'ancien, qui garde le volume, et la mise à jour resterait bloquée.
+func (g *Generator) deploymentStrategy(name string, cfg config.ServiceConfig) *k8s.DeploymentStrategy {
+	strategy := cfg.Strategy
+	if strategy == "" && len(g.exclusiveClaims(name, cfg)) > 0 {
+		strategy = "Recreate"
+	}
+	if strategy == "" {
+		return nil
+	}
+	return &k8s.DeploymentStrategy{Type: strategy}
+}
+
+// strategyWarnings signale les services à plusieurs réplicas montés sur un
+// volume exclusif : les pods supplémentaires ne pourront pas démarrer.
+func (g *Generator) strategyWarnings() []string {
+	var warnings []string
+	for _, svc := range g.services() {
+		if !svc.config.Enabled || svc.config.Replicas == nil || *svc.config.Replicas <= 1 {
+			continue
+		}
+		if claims := g.exclusiveClaims(svc.name, svc.config); len(claims) > 0 {
+			warnings = append(warnings, fmt.Sprintf(
+				"services.%s.replicas: %d réplicas avec des volumes ReadWriteOnce (%s), seul le premier pod pourra les monter",
+				svc.name, *svc.config.Replicas, strings.Join(claims, ", ")))
+		}
+	}
+	return warnings
+}
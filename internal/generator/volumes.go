@@ -0,0 +1,264 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"teleflix/internal/config"
+	"teleflix/internal/k8s"
+)
+
+// Sous-dossiers du volume data (disposition unified) : les téléchargements
+// et les médias y ont le même chemin dans tous les services.
+var dataSubPaths = map[string]string{
+	"downloads": "torrents",
+	"media":     "media",
+}
+
+// smbDriver est le driver CSI des partages SMB (csi-driver-smb).
+const smbDriver = "smb.csi.k8s.io"
+
+// volumeMount est un volume du service résolu vers sa source : le PVC
+// monté, ou à défaut la source montée directement dans le pod.
+type volumeMount struct {
+	Volume    string // Nom du volume dans le pod
+	Claim     string // PVC monté, vide pour une source directe
+	Source    config.VolumeSourceConfig
+	MountPath string
+	SubPath   string // Sous-dossier du volume, vide pour sa racine
+	ReadOnly  bool
+	Block     bool // Périphérique brut (volumeMode Block) exposé à MountPath
+
+	// Modes d'accès du PVC généré, vide pour un PVC existant ou une source
+	// directe
+	AccessModes []string
+}
+
+func (g *Generator) unifiedLayout() bool {
+	return g.config.Storage.Layout == "unified"
+}
+
+// sharedVolume retourne la configuration du PVC partagé media, downloads
+// ou data.
+func (g *Generator) sharedVolume(name string) config.SharedVolumeConfig {
+	switch name {
+	case "media":
+		return g.config.Storage.Media
+	case "downloads":
+		return g.config.Storage.Downloads
+	default:
+		return g.config.Storage.Data
+	}
+}
+
+func (g *Generator) sharedAccessModes(name string) []string {
+	vol := g.sharedVolume(name)
+	if vol.ExistingClaim != "" {
+		return nil
+	}
+	return vol.AccessModes
+}
+
+func (g *Generator) sharedClaim(name string) string {
+	if claim := g.sharedVolume(name).ExistingClaim; claim != "" {
+		return claim
+	}
+	return name + "-pvc"
+}
+
+// volumeMounts résout les volumes d'un service. En disposition unified,
+// media et downloads deviennent un seul montage du volume data : sa racine
+// /data si le service utilise les deux (hardlinks entre torrents/ et
+// media/), sinon le seul sous-dossier utile, monté au même chemin.
+func (g *Generator) volumeMounts(name string, cfg config.ServiceConfig) []volumeMount {
+	var mounts []volumeMount
+	data := -1
+	for _, vol := range cfg.Volumes {
+		switch {
+		case g.unifiedLayout() && isSharedVolume(vol.Name):
+			subPath := dataSubPaths[vol.Name]
+			if data >= 0 {
+				// Le service utilise media et downloads : racine du volume
+				mounts[data].MountPath = config.UnifiedDataPath
+				mounts[data].SubPath = ""
+				mounts[data].ReadOnly = mounts[data].ReadOnly && vol.ReadOnly
+				continue
+			}
+			data = len(mounts)
+			mounts = append(mounts, volumeMount{
+				Volume:      "data",
+				Claim:       g.sharedClaim("data"),
+				Source:      g.sharedVolume("data").VolumeSourceConfig,
+				AccessModes: g.sharedAccessModes("data"),
+				MountPath:   path.Join(config.UnifiedDataPath, subPath),
+				SubPath:     subPath,
+				ReadOnly:    vol.ReadOnly,
+			})
+		case isSharedVolume(vol.Name):
+			mounts = append(mounts, volumeMount{
+				Volume:      vol.Name,
+				Claim:       g.sharedClaim(vol.Name),
+				Source:      g.sharedVolume(vol.Name).VolumeSourceConfig,
+				AccessModes: g.sharedAccessModes(vol.Name),
+				MountPath:   vol.MountPath,
+				ReadOnly:    vol.ReadOnly,
+			})
+		default:
+			m := volumeMount{Volume: vol.Name, Source: vol.VolumeSourceConfig, MountPath: vol.MountPath, ReadOnly: vol.ReadOnly, Block: vol.VolumeMode == "Block"}
+			switch {
+			case vol.ExistingClaim != "":
+				m.Claim = vol.ExistingClaim
+			case !vol.Inline():
+				m.Claim = fmt.Sprintf("%s-%s-pvc", name, vol.Name)
+				m.AccessModes = serviceClaimSpec(vol).AccessModes
+			}
+			mounts = append(mounts, m)
+		}
+	}
+	return mounts
+}
+
+func isSharedVolume(name string) bool {
+	_, ok := dataSubPaths[name]
+	return ok
+}
+
+// podVolumes construit les montages et périphériques du conteneur et les
+// volumes du pod.
+func (g *Generator) podVolumes(name string, cfg config.ServiceConfig) ([]k8s.VolumeMount, []k8s.VolumeDevice, []k8s.Volume) {
+	var volumeMounts []k8s.VolumeMount
+	var volumeDevices []k8s.VolumeDevice
+	var volumes []k8s.Volume
+	for _, m := range g.volumeMounts(name, cfg) {
+		if m.Block {
+			volumeDevices = append(volumeDevices, k8s.VolumeDevice{Name: m.Volume, DevicePath: m.MountPath})
+		} else {
+			volumeMounts = append(volumeMounts, k8s.VolumeMount{
+				Name:      m.Volume,
+				MountPath: m.MountPath,
+				SubPath:   m.SubPath,
+				ReadOnly:  m.ReadOnly,
+			})
+		}
+
+		var source k8s.VolumeSource
+		switch {
+		case m.Claim != "":
+			source.PersistentVolumeClaim = &k8s.PersistentVolumeClaimVolumeSource{ClaimName: m.Claim}
+		case m.Source.EmptyDir:
+			source.EmptyDir = &k8s.EmptyDirVolumeSource{}
+		case m.Source.HostPath != "":
+			source.HostPath = &k8s.HostPathVolumeSource{Path: m.Source.HostPath}
+		case m.Source.NFS != nil:
+			source.NFS = &k8s.NFSVolumeSource{Server: m.Source.NFS.Server, Path: m.Source.NFS.Path, ReadOnly: m.ReadOnly}
+		}
+		volumes = append(volumes, k8s.Volume{Name: m.Volume, VolumeSource: source})
+	}
+	return volumeMounts, volumeDevices, volumes
+}
+
+// claimSpec regroupe les options d'un PVC généré.
+type claimSpec struct {
+	Size         string
+	AccessModes  []string
+	StorageClass string // Vide : storageClass globale, ou aucune pour un PV statique
+	VolumeMode   string
+	Annotations  map[string]string
+}
+
+func serviceClaimSpec(vol config.VolumeConfig) claimSpec {
+	accessModes := vol.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []string{"ReadWriteOnce"}
+	}
+	return claimSpec{
+		Size:         vol.Size,
+		AccessModes:  accessModes,
+		StorageClass: vol.StorageClass,
+		VolumeMode:   vol.VolumeMode,
+		Annotations:  vol.Annotations,
+	}
+}
+
+func sharedClaimSpec(vol config.SharedVolumeConfig) claimSpec {
+	return claimSpec{
+		Size:         vol.Size,
+		AccessModes:  vol.AccessModes,
+		StorageClass: vol.StorageClass,
+		Annotations:  vol.Annotations,
+	}
+}
+
+// claimObjects retourne le PVC name et, pour une source hostPath, nfs ou smb,
+// le PersistentVolume statique auquel il est lié. Le PV conserve les données
+// à la suppression du PVC (Retain).
+func (g *Generator) claimObjects(name string, spec claimSpec, src config.VolumeSourceConfig) []any {
+	storageClass := spec.StorageClass
+	if storageClass == "" && !src.Static() {
+		storageClass = g.config.StorageClass
+	}
+
+	pvc := &k8s.PersistentVolumeClaim{
+		TypeMeta: k8s.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: k8s.ObjectMeta{
+			Name:        name,
+			Namespace:   g.config.Namespace,
+			Annotations: spec.Annotations,
+		},
+		Spec: k8s.PersistentVolumeClaimSpec{
+			AccessModes: spec.AccessModes,
+			Resources: k8s.ResourceRequirements{
+				Requests: map[string]string{
+					"storage": spec.Size,
+				},
+			},
+			StorageClassName: &storageClass,
+			VolumeMode:       spec.VolumeMode,
+		},
+	}
+	if !src.Static() {
+		return []any{pvc}
+	}
+
+	// Les PV n'ont pas de namespace : leur nom le reprend pour rester unique
+	pvName := fmt.Sprintf("%s-%s", g.config.Namespace, strings.TrimSuffix(name, "-pvc"))
+	pv := &k8s.PersistentVolume{
+		TypeMeta: k8s.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "PersistentVolume",
+		},
+		ObjectMeta: k8s.ObjectMeta{
+			Name: pvName,
+		},
+		Spec: k8s.PersistentVolumeSpec{
+			Capacity:                      map[string]string{"storage": spec.Size},
+			AccessModes:                   spec.AccessModes,
+			PersistentVolumeReclaimPolicy: "Retain",
+			StorageClassName:              storageClass,
+			VolumeMode:                    spec.VolumeMode,
+			ClaimRef:                      &k8s.ObjectReference{Namespace: g.config.Namespace, Name: name},
+		},
+	}
+	switch {
+	case src.HostPath != "":
+		pv.Spec.HostPath = &k8s.HostPathVolumeSource{Path: src.HostPath, Type: "Directory"}
+	case src.NFS != nil:
+		pv.Spec.NFS = &k8s.NFSVolumeSource{Server: src.NFS.Server, Path: src.NFS.Path}
+	case src.SMB != nil:
+		pv.Spec.MountOptions = src.SMB.MountOptions
+		pv.Spec.CSI = &k8s.CSIPersistentVolumeSource{
+			Driver:             smbDriver,
+			VolumeHandle:       pvName,
+			VolumeAttributes:   map[string]string{"source": src.SMB.Source},
+			NodeStageSecretRef: &k8s.SecretReference{Name: src.SMB.SecretName, Namespace: g.config.Namespace},
+		}
+	}
+
+	// Sans storageClass, le PVC ne déclenche aucun provisionnement dynamique
+	pvc.Spec.VolumeName = pvName
+	return []any{pv, pvc}
+}
@@ -0,0 +1,158 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"teleflix/internal/config"
+	"teleflix/internal/k8s"
+)
+
+const defaultVPNImage = "qmcgaw/gluetun:latest"
+
+// defaultOutboundSubnets couvre les plages par défaut des pods et des
+// Services de k3s : CoreDNS et les services liés restent joignables.
+var defaultOutboundSubnets = []string{"10.42.0.0/16", "10.43.0.0/16"}
+
+// vpnHealthcheck interroge le serveur de santé interne de gluetun ; une
+// commande évite d'ouvrir un port supplémentaire dans son pare-feu.
+var vpnHealthcheck = []string{"/gluetun-entrypoint", "healthcheck"}
+
+// vpnSidecar construit le conteneur gluetun, déclaré en sidecar natif
+// (initContainer avec restartPolicy Always) : le service ne démarre
+// qu'une fois le tunnel établi et le pare-feu (kill switch) en place.
+// Il partage le réseau du pod, donc tout le trafic du service passe par
+// le tunnel.
+func vpnSidecar(name string, cfg config.ServiceConfig) k8s.Container {
+	vpn := cfg.VPN
+
+	image := vpn.Image
+	if image == "" {
+		image = defaultVPNImage
+	}
+	secretName := vpn.SecretName
+	if secretName == "" {
+		secretName = name + "-vpn"
+	}
+	vpnType := vpn.Type
+	if vpnType == "" {
+		vpnType = "wireguard"
+	}
+
+	// Le pare-feu de gluetun refuse toute connexion entrante hors du
+	// tunnel : les ports du service (et de ses sondes) doivent y être
+	// ouverts pour les Services Kubernetes et le kubelet. Les ports
+	// remplacés par le port redirigé ne sont ouverts que dans le tunnel.
+	inputPorts := []string{strconv.Itoa(int(cfg.Port))}
+	addInputPort := func(port int32) {
+		if p := strconv.Itoa(int(port)); !slices.Contains(inputPorts, p) {
+			inputPorts = append(inputPorts, p)
+		}
+	}
+	if cfg.Probes.Port != 0 {
+		addInputPort(cfg.Probes.Port)
+	}
+	for _, p := range cfg.Ports {
+		if p.ServiceType == "" || vpn.ForwardedPort == 0 {
+			addInputPort(p.Port)
+		}
+	}
+
+	// Le kill switch bloque aussi le réseau du cluster : ses plages sont
+	// autorisées hors du tunnel, et le DNS du pod (CoreDNS) est conservé
+	// pour résoudre les noms *.svc au lieu du DNS chiffré de gluetun
+	outboundSubnets := vpn.OutboundSubnets
+	if len(outboundSubnets) == 0 {
+		outboundSubnets = defaultOutboundSubnets
+	}
+
+	env := map[string]string{
+		"VPN_SERVICE_PROVIDER":      vpn.Provider,
+		"VPN_TYPE":                  vpnType,
+		"FIREWALL_INPUT_PORTS":      strings.Join(inputPorts, ","),
+		"FIREWALL_OUTBOUND_SUBNETS": strings.Join(outboundSubnets, ","),
+		"DNS_KEEP_NAMESERVER":       "on",
+	}
+	if vpn.ForwardedPort != 0 {
+		env["FIREWALL_VPN_INPUT_PORTS"] = strconv.Itoa(int(vpn.ForwardedPort))
+	}
+	if vpn.PortForwarding {
+		// Reporte le port négocié dans qBittorrent (authentification
+		// locale désactivée requise : "Bypass authentication for clients
+		// on localhost")
+		env["VPN_PORT_FORWARDING"] = "on"
+		env["VPN_PORT_FORWARDING_UP_COMMAND"] = fmt.Sprintf(
+			`/bin/sh -c 'wget -O- --retry-connrefused --post-data "json={\"listen_port\":{{PORTS}}}" http://127.0.0.1:%d/api/v2/app/setPreferences 2>&1'`,
+			cfg.Port)
+	}
+	for key, value := range vpn.Environment {
+		env[key] = value
+	}
+
+	var envVars []k8s.EnvVar
+	for _, key := range sortedKeys(env) {
+		envVars = append(envVars, k8s.EnvVar{Name: key, Value: env[key]})
+	}
+
+	// Sans le périphérique de l'hôte, gluetun crée /dev/net/tun au
+	// démarrage, ce qui requiert MKNOD
+	capabilities := []string{"NET_ADMIN"}
+	var mounts []k8s.VolumeMount
+	if vpn.HostTun {
+		mounts = append(mounts, k8s.VolumeMount{Name: "tun", MountPath: "/dev/net/tun"})
+	} else {
+		capabilities = append(capabilities, "MKNOD")
+	}
+
+	return k8s.Container{
+		Name:          "vpn",
+		Image:         image,
+		RestartPolicy: "Always",
+		Env:           envVars,
+		EnvFrom: []k8s.EnvFromSource{
+			{SecretRef: &k8s.SecretEnvSource{Name: secretName}},
+		},
+		VolumeMounts: mounts,
+		Resources: k8s.ResourceRequirements{
+			Requests: map[string]string{"cpu": "50m", "memory": "64Mi"},
+			Limits:   map[string]string{"cpu": "500m", "memory": "256Mi"},
+		},
+		// Le démarrage du service attend la réussite de startupProbe
+		StartupProbe: &k8s.Probe{
+			ProbeHandler:     k8s.ProbeHandler{Exec: &k8s.ExecAction{Command: vpnHealthcheck}},
+			PeriodSeconds:    5,
+			TimeoutSeconds:   5,
+			FailureThreshold: 24,
+		},
+		LivenessProbe: &k8s.Probe{
+			ProbeHandler:     k8s.ProbeHandler{Exec: &k8s.ExecAction{Command: vpnHealthcheck}},
+			PeriodSeconds:    30,
+			TimeoutSeconds:   5,
+			FailureThreshold: 3,
+		},
+		// Root et NET_ADMIN sont nécessaires pour créer l'interface du
+		// tunnel et les règles iptables du kill switch ; les autres
+		// capabilities sont retirées
+		SecurityContext: &k8s.SecurityContext{
+			RunAsUser:                int64Ptr(0),
+			RunAsNonRoot:             boolPtr(false),
+			AllowPrivilegeEscalation: boolPtr(false),
+			Capabilities: &k8s.Capabilities{
+				Drop: []string{"ALL"},
+				Add:  capabilities,
+			},
+		},
+	}
+}
+
+// vpnVolume expose le périphérique TUN de l'hôte au sidecar (vpn.hostTun).
+func vpnVolume() k8s.Volume {
+	return k8s.Volume{
+		Name: "tun",
+		VolumeSource: k8s.VolumeSource{
+			HostPath: &k8s.HostPathVolumeSource{Path: "/dev/net/tun", Type: "CharDevice"},
+		},
+	}
+}
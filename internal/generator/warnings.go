@@ -0,0 +1,22 @@
+package generator
+
+// Warnings retourne les avertissements de la génération Kubernetes : champs
+// des pods refusés par les niveaux audit et warn du namespace, puis ceux de
+// ConfigWarnings.
+func (g *Generator) Warnings() ([]string, error) {
+	manifests, err := g.Manifests()
+	if err != nil {
+		return nil, err
+	}
+	return append(g.podSecurityWarnings(manifests), g.ConfigWarnings()...), nil
+}
+
+// ConfigWarnings retourne les avertissements valables quel que soit le format
+// de sortie : ports publiés ignorés, chemins de téléchargement incohérents et
+// réplicas sur volumes exclusifs.
+func (g *Generator) ConfigWarnings() []string {
+	var warnings []string
+	warnings = append(warnings, g.portWarnings()...)
+	warnings = append(warnings, g.pathMappingWarnings()...)
+	return append(warnings, g.strategyWarnings()...)
+}
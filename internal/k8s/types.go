@@ -19,6 +19,13 @@ type Namespace struct {
 	ObjectMeta `yaml:"metadata"`
 }
 
+// ConfigMap
+type ConfigMap struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Data       map[string]string `yaml:"data,omitempty"`
+}
+
 // PersistentVolumeClaim
 type PersistentVolumeClaim struct {
 	TypeMeta   `yaml:",inline"`
@@ -30,6 +37,46 @@ type PersistentVolumeClaimSpec struct {
 	AccessModes      []string             `yaml:"accessModes"`
 	Resources        ResourceRequirements `yaml:"resources"`
 	StorageClassName *string              `yaml:"storageClassName,omitempty"`
+	VolumeMode       string               `yaml:"volumeMode,omitempty"`
+	VolumeName       string               `yaml:"volumeName,omitempty"`
+}
+
+// PersistentVolume
+type PersistentVolume struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       PersistentVolumeSpec `yaml:"spec"`
+}
+
+type PersistentVolumeSpec struct {
+	Capacity                      map[string]string          `yaml:"capacity"`
+	AccessModes                   []string                   `yaml:"accessModes"`
+	PersistentVolumeReclaimPolicy string                     `yaml:"persistentVolumeReclaimPolicy,omitempty"`
+	StorageClassName              string                     `yaml:"storageClassName"`
+	VolumeMode                    string                     `yaml:"volumeMode,omitempty"`
+	MountOptions                  []string                   `yaml:"mountOptions,omitempty"`
+	ClaimRef                      *ObjectReference           `yaml:"claimRef,omitempty"`
+	NFS                           *NFSVolumeSource           `yaml:"nfs,omitempty"`
+	HostPath                      *HostPathVolumeSource      `yaml:"hostPath,omitempty"`
+	CSI                           *CSIPersistentVolumeSource `yaml:"csi,omitempty"`
+}
+
+type ObjectReference struct {
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+}
+
+type CSIPersistentVolumeSource struct {
+	Driver             string            `yaml:"driver"`
+	VolumeHandle       string            `yaml:"volumeHandle"`
+	ReadOnly           bool              `yaml:"readOnly,omitempty"`
+	VolumeAttributes   map[string]string `yaml:"volumeAttributes,omitempty"`
+	NodeStageSecretRef *SecretReference  `yaml:"nodeStageSecretRef,omitempty"`
+}
+
+type SecretReference struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
 }
 
 // Deployment
@@ -40,9 +87,14 @@ type Deployment struct {
 }
 
 type DeploymentSpec struct {
-	Replicas *int32          `yaml:"replicas,omitempty"`
-	Selector *LabelSelector  `yaml:"selector"`
-	Template PodTemplateSpec `yaml:"template"`
+	Replicas *int32              `yaml:"replicas,omitempty"`
+	Selector *LabelSelector      `yaml:"selector"`
+	Strategy *DeploymentStrategy `yaml:"strategy,omitempty"`
+	Template PodTemplateSpec     `yaml:"template"`
+}
+
+type DeploymentStrategy struct {
+	Type string `yaml:"type"` // Recreate ou RollingUpdate
 }
 
 type LabelSelector struct {
@@ -55,20 +107,91 @@ type PodTemplateSpec struct {
 }
 
 type PodSpec struct {
-	Containers []Container `yaml:"containers"`
-	Volumes    []Volume    `yaml:"volumes,omitempty"`
+	SecurityContext *PodSecurityContext `yaml:"securityContext,omitempty"`
+	InitContainers  []Container         `yaml:"initContainers,omitempty"`
+	Containers      []Container         `yaml:"containers"`
+	Volumes         []Volume            `yaml:"volumes,omitempty"`
+}
+
+type PodSecurityContext struct {
+	RunAsUser      *int64          `yaml:"runAsUser,omitempty"`
+	RunAsGroup     *int64          `yaml:"runAsGroup,omitempty"`
+	RunAsNonRoot   *bool           `yaml:"runAsNonRoot,omitempty"`
+	FSGroup        *int64          `yaml:"fsGroup,omitempty"`
+	SeccompProfile *SeccompProfile `yaml:"seccompProfile,omitempty"`
+}
+
+type SeccompProfile struct {
+	Type string `yaml:"type"`
 }
 
 type Container struct {
-	Name         string               `yaml:"name"`
-	Image        string               `yaml:"image"`
-	Ports        []ContainerPort      `yaml:"ports,omitempty"`
-	Env          []EnvVar             `yaml:"env,omitempty"`
-	VolumeMounts []VolumeMount        `yaml:"volumeMounts,omitempty"`
-	Resources    ResourceRequirements `yaml:"resources,omitempty"`
+	Name            string               `yaml:"name"`
+	Image           string               `yaml:"image"`
+	Command         []string             `yaml:"command,omitempty"`
+	Ports           []ContainerPort      `yaml:"ports,omitempty"`
+	Env             []EnvVar             `yaml:"env,omitempty"`
+	EnvFrom         []EnvFromSource      `yaml:"envFrom,omitempty"`
+	VolumeMounts    []VolumeMount        `yaml:"volumeMounts,omitempty"`
+	VolumeDevices   []VolumeDevice       `yaml:"volumeDevices,omitempty"`
+	Resources       ResourceRequirements `yaml:"resources,omitempty"`
+	LivenessProbe   *Probe               `yaml:"livenessProbe,omitempty"`
+	ReadinessProbe  *Probe               `yaml:"readinessProbe,omitempty"`
+	StartupProbe    *Probe               `yaml:"startupProbe,omitempty"`
+	SecurityContext *SecurityContext     `yaml:"securityContext,omitempty"`
+	RestartPolicy   string               `yaml:"restartPolicy,omitempty"` // Always : sidecar natif (initContainers)
+}
+
+type EnvFromSource struct {
+	SecretRef *SecretEnvSource `yaml:"secretRef,omitempty"`
+}
+
+type SecretEnvSource struct {
+	Name string `yaml:"name"`
+}
+
+type SecurityContext struct {
+	RunAsUser                *int64        `yaml:"runAsUser,omitempty"`
+	RunAsNonRoot             *bool         `yaml:"runAsNonRoot,omitempty"`
+	AllowPrivilegeEscalation *bool         `yaml:"allowPrivilegeEscalation,omitempty"`
+	ReadOnlyRootFilesystem   *bool         `yaml:"readOnlyRootFilesystem,omitempty"`
+	Capabilities             *Capabilities `yaml:"capabilities,omitempty"`
+}
+
+type Capabilities struct {
+	Add  []string `yaml:"add,omitempty"`
+	Drop []string `yaml:"drop,omitempty"`
+}
+
+type Probe struct {
+	ProbeHandler        `yaml:",inline"`
+	InitialDelaySeconds int32 `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int32 `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      int32 `yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold    int32 `yaml:"failureThreshold,omitempty"`
+}
+
+type ProbeHandler struct {
+	Exec      *ExecAction      `yaml:"exec,omitempty"`
+	HTTPGet   *HTTPGetAction   `yaml:"httpGet,omitempty"`
+	TCPSocket *TCPSocketAction `yaml:"tcpSocket,omitempty"`
+}
+
+type ExecAction struct {
+	Command []string `yaml:"command"`
+}
+
+type HTTPGetAction struct {
+	Path string `yaml:"path"`
+	Port int32  `yaml:"port"`
+}
+
+type TCPSocketAction struct {
+	Port int32 `yaml:"port"`
 }
 
 type ContainerPort struct {
+	Name          string `yaml:"name,omitempty"`
 	ContainerPort int32  `yaml:"containerPort"`
 	Protocol      string `yaml:"protocol,omitempty"`
 }
@@ -81,9 +204,15 @@ type EnvVar struct {
 type VolumeMount struct {
 	Name      string `yaml:"name"`
 	MountPath string `yaml:"mountPath"`
+	SubPath   string `yaml:"subPath,omitempty"`
 	ReadOnly  bool   `yaml:"readOnly,omitempty"`
 }
 
+type VolumeDevice struct {
+	Name       string `yaml:"name"`
+	DevicePath string `yaml:"devicePath"`
+}
+
 type Volume struct {
 	Name         string `yaml:"name"`
 	VolumeSource `yaml:",inline"`
@@ -91,6 +220,29 @@ type Volume struct {
 
 type VolumeSource struct {
 	PersistentVolumeClaim *PersistentVolumeClaimVolumeSource `yaml:"persistentVolumeClaim,omitempty"`
+	EmptyDir              *EmptyDirVolumeSource              `yaml:"emptyDir,omitempty"`
+	HostPath              *HostPathVolumeSource              `yaml:"hostPath,omitempty"`
+	NFS                   *NFSVolumeSource                   `yaml:"nfs,omitempty"`
+	ConfigMap             *ConfigMapVolumeSource             `yaml:"configMap,omitempty"`
+}
+
+type ConfigMapVolumeSource struct {
+	Name string `yaml:"name"`
+}
+
+type NFSVolumeSource struct {
+	Server   string `yaml:"server"`
+	Path     string `yaml:"path"`
+	ReadOnly bool   `yaml:"readOnly,omitempty"`
+}
+
+type HostPathVolumeSource struct {
+	Path string `yaml:"path"`
+	Type string `yaml:"type,omitempty"`
+}
+
+type EmptyDirVolumeSource struct {
+	Medium string `yaml:"medium,omitempty"`
 }
 
 type PersistentVolumeClaimVolumeSource struct {
@@ -110,14 +262,19 @@ type Service struct {
 }
 
 type ServiceSpec struct {
-	Selector map[string]string `yaml:"selector"`
-	Ports    []ServicePort     `yaml:"ports"`
+	Type                  string            `yaml:"type,omitempty"`
+	Selector              map[string]string `yaml:"selector"`
+	Ports                 []ServicePort     `yaml:"ports"`
+	LoadBalancerIP        string            `yaml:"loadBalancerIP,omitempty"`
+	ExternalTrafficPolicy string            `yaml:"externalTrafficPolicy,omitempty"`
 }
 
 type ServicePort struct {
+	Name       string `yaml:"name,omitempty"`
 	Port       int32  `yaml:"port"`
 	TargetPort int32  `yaml:"targetPort"`
 	Protocol   string `yaml:"protocol,omitempty"`
+	NodePort   int32  `yaml:"nodePort,omitempty"`
 }
 
 // Ingress
@@ -170,6 +327,46 @@ type ServiceBackendPort struct {
 	Number int32 `yaml:"number"`
 }
 
+// NetworkPolicy
+type NetworkPolicy struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata"`
+	Spec       NetworkPolicySpec `yaml:"spec"`
+}
+
+type NetworkPolicySpec struct {
+	PodSelector LabelSelector              `yaml:"podSelector"`
+	PolicyTypes []string                   `yaml:"policyTypes,omitempty"`
+	Ingress     []NetworkPolicyIngressRule `yaml:"ingress,omitempty"`
+	Egress      []NetworkPolicyEgressRule  `yaml:"egress,omitempty"`
+}
+
+type NetworkPolicyIngressRule struct {
+	From  []NetworkPolicyPeer `yaml:"from,omitempty"`
+	Ports []NetworkPolicyPort `yaml:"ports,omitempty"`
+}
+
+type NetworkPolicyEgressRule struct {
+	To    []NetworkPolicyPeer `yaml:"to,omitempty"`
+	Ports []NetworkPolicyPort `yaml:"ports,omitempty"`
+}
+
+type NetworkPolicyPeer struct {
+	PodSelector       *LabelSelector `yaml:"podSelector,omitempty"`
+	NamespaceSelector *LabelSelector `yaml:"namespaceSelector,omitempty"`
+	IPBlock           *IPBlock       `yaml:"ipBlock,omitempty"`
+}
+
+type IPBlock struct {
+	CIDR   string   `yaml:"cidr"`
+	Except []string `yaml:"except,omitempty"`
+}
+
+type NetworkPolicyPort struct {
+	Protocol string `yaml:"protocol,omitempty"`
+	Port     *int32 `yaml:"port,omitempty"`
+}
+
 // Cert-Manager Types
 type ClusterIssuer struct {
 	TypeMeta   `yaml:",inline"`
@@ -0,0 +1,20 @@
+package kustomize
+
+// Types du fichier kustomization.yaml (kustomize v5)
+type Kustomization struct {
+	APIVersion string    `yaml:"apiVersion"`
+	Kind       string    `yaml:"kind"`
+	Namespace  string    `yaml:"namespace,omitempty"`
+	Resources  []string  `yaml:"resources"`
+	Patches    []Patch   `yaml:"patches,omitempty"`
+	Replicas   []Replica `yaml:"replicas,omitempty"`
+}
+
+type Patch struct {
+	Path string `yaml:"path"`
+}
+
+type Replica struct {
+	Name  string `yaml:"name"`
+	Count int32  `yaml:"count"`
+}
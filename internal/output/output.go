@@ -0,0 +1,182 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockFile liste, à la racine du répertoire de sortie, les fichiers écrits
+// lors de la dernière génération. Son nom ne se termine pas par .yaml afin
+// que kubectl apply -f l'ignore.
+const LockFile = ".teleflix.lock"
+
+const lockHeader = "# Fichiers générés par teleflix, supprimés automatiquement s'ils ne sont plus produits.\n# Ne pas modifier.\n"
+
+// generatedName reconnaît les noms de fichiers produits par teleflix
+// (ex: 03-jellyfin.yaml) ; seuls ces fichiers non suivis sont signalés.
+var generatedName = regexp.MustCompile(`^[0-9]{2}-[a-z0-9-]+\.(yaml|yml|json)$`)
+
+type lock struct {
+	Files []string `yaml:"files"`
+}
+
+// Result résume une synchronisation. Les chemins sont relatifs au
+// répertoire de sortie, avec des / comme séparateurs.
+type Result struct {
+	Written   []string
+	Removed   []string // Fichiers orphelins supprimés
+	Untracked []string // Fichiers orphelins absents du lock, conservés
+}
+
+// Sync écrit les fichiers dans dir puis supprime ceux de la génération
+// précédente qui ne sont plus produits. Les fichiers ressemblant à des
+// manifests mais absents du lock (générés avant son introduction, ou
+// ajoutés à la main) sont seulement signalés, sauf si prune est vrai.
+func Sync(dir string, files map[string]string, prune bool) (*Result, error) {
+	previous, err := readLock(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("erreur lors de la création du répertoire: %w", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &Result{}
+	for _, name := range names {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return nil, fmt.Errorf("erreur lors de la création du répertoire: %w", err)
+		}
+		if err := os.WriteFile(file, []byte(files[name]), 0644); err != nil {
+			return nil, fmt.Errorf("erreur lors de l'écriture de %s: %w", file, err)
+		}
+		result.Written = append(result.Written, name)
+	}
+
+	tracked := make(map[string]bool, len(previous))
+	for _, name := range previous {
+		// Un lock modifié à la main ne doit pas faire sortir de dir
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			continue
+		}
+		tracked[name] = true
+		if _, ok := files[name]; ok {
+			continue
+		}
+		if err := remove(dir, name); err != nil {
+			return nil, err
+		}
+		result.Removed = append(result.Removed, name)
+	}
+
+	untracked, err := findUntracked(dir, files, tracked)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range untracked {
+		if !prune {
+			result.Untracked = append(result.Untracked, name)
+			continue
+		}
+		if err := remove(dir, name); err != nil {
+			return nil, err
+		}
+		result.Removed = append(result.Removed, name)
+	}
+	sort.Strings(result.Removed)
+
+	if err := writeLock(dir, names); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func readLock(dir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, LockFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var l lock
+	if err := yaml.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, LockFile), err)
+	}
+	return l.Files, nil
+}
+
+func writeLock(dir string, names []string) error {
+	data, err := yaml.Marshal(&lock{Files: names})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, LockFile), append([]byte(lockHeader), data...), 0644)
+}
+
+// findUntracked retourne les fichiers au nom de manifest généré qui ne sont
+// ni produits par cette génération ni suivis par le lock. Seuls les
+// répertoires où teleflix écrit sont parcourus, sans descendre plus bas.
+func findUntracked(dir string, files map[string]string, tracked map[string]bool) ([]string, error) {
+	dirs := map[string]bool{".": true}
+	for name := range files {
+		dirs[path.Dir(name)] = true
+	}
+	for name := range tracked {
+		dirs[path.Dir(name)] = true
+	}
+
+	var untracked []string
+	for sub := range dirs {
+		entries, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(sub)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !generatedName.MatchString(entry.Name()) {
+				continue
+			}
+			name := path.Join(sub, entry.Name())
+			if _, ok := files[name]; !ok && !tracked[name] {
+				untracked = append(untracked, name)
+			}
+		}
+	}
+	sort.Strings(untracked)
+	return untracked, nil
+}
+
+// remove supprime un fichier puis ses répertoires parents devenus vides,
+// sans jamais remonter au-delà de dir.
+func remove(dir, name string) error {
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("erreur lors de la suppression de %s: %w", file, err)
+	}
+
+	root := filepath.Clean(dir)
+	for parent := filepath.Dir(file); parent != root && parent != "."; parent = filepath.Dir(parent) {
+		if os.Remove(parent) != nil {
+			break // Répertoire non vide
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestSyncRemovesTrackedOrphans(t *testing.T) {
+	dir := t.TempDir()
+
+	first := map[string]string{
+		"00-namespace.yaml":        "a",
+		"03-jackett.yaml":          "b",
+		"03-sonarr.yaml":           "c",
+		"templates/03-radarr.yaml": "d",
+	}
+	if _, err := Sync(dir, first, false); err != nil {
+		t.Fatal(err)
+	}
+
+	second := map[string]string{
+		"00-namespace.yaml": "a",
+		"03-sonarr.yaml":    "c2",
+	}
+	result, err := Sync(dir, second, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"03-jackett.yaml", "templates/03-radarr.yaml"}; !reflect.DeepEqual(result.Removed, want) {
+		t.Errorf("Removed = %v, attendu %v", result.Removed, want)
+	}
+	if len(result.Untracked) != 0 {
+		t.Errorf("Untracked = %v, attendu aucun", result.Untracked)
+	}
+	if exists(filepath.Join(dir, "03-jackett.yaml")) {
+		t.Error("03-jackett.yaml aurait dû être supprimé")
+	}
+	if exists(filepath.Join(dir, "templates")) {
+		t.Error("le répertoire templates vide aurait dû être supprimé")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "03-sonarr.yaml")); string(data) != "c2" {
+		t.Errorf("03-sonarr.yaml = %q, attendu c2", data)
+	}
+}
+
+func TestSyncReportsUntrackedFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	// Ancienne génération sans lock, et fichiers sans rapport avec teleflix
+	writeFile(t, filepath.Join(dir, "05-radarr.yaml"), "old")
+	writeFile(t, filepath.Join(dir, "config.yaml"), "keep")
+	writeFile(t, filepath.Join(dir, "other", "04-sonarr.yaml"), "keep")
+
+	files := map[string]string{"03-radarr.yaml": "new"}
+	result, err := Sync(dir, files, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"05-radarr.yaml"}; !reflect.DeepEqual(result.Untracked, want) {
+		t.Fatalf("Untracked = %v, attendu %v", result.Untracked, want)
+	}
+	if !exists(filepath.Join(dir, "05-radarr.yaml")) {
+		t.Fatal("un fichier non suivi ne doit pas être supprimé sans prune")
+	}
+
+	result, err = Sync(dir, files, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"05-radarr.yaml"}; !reflect.DeepEqual(result.Removed, want) {
+		t.Errorf("Removed = %v, attendu %v", result.Removed, want)
+	}
+	for _, kept := range []string{"config.yaml", "other/04-sonarr.yaml"} {
+		if !exists(filepath.Join(dir, filepath.FromSlash(kept))) {
+			t.Errorf("%s ne doit jamais être supprimé", kept)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package podsecurity vérifie statiquement qu'un pod respecte un niveau des
+// Pod Security Standards, comme le ferait l'admission Kubernetes.
+//
+// Seuls les champs que teleflix sait générer sont contrôlés.
+package podsecurity
+
+import (
+	"fmt"
+	"slices"
+
+	"teleflix/internal/k8s"
+)
+
+// Niveaux des Pod Security Standards, du plus permissif au plus strict.
+const (
+	Privileged = "privileged"
+	Baseline   = "baseline"
+	Restricted = "restricted"
+)
+
+// baselineCapabilities sont les capabilities qu'un conteneur peut ajouter au
+// niveau baseline ; restricted n'autorise que NET_BIND_SERVICE.
+var baselineCapabilities = []string{
+	"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD",
+	"NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT",
+}
+
+// Violation décrit un champ du pod refusé par le niveau vérifié.
+type Violation struct {
+	Field   string // Chemin relatif au pod, ex: containers[sonarr].securityContext.capabilities.add
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Field, v.Message)
+}
+
+// Check retourne les champs de spec refusés au niveau donné. Un niveau vide
+// ou privileged n'impose aucune règle.
+func Check(level string, spec *k8s.PodSpec) []Violation {
+	var c checker
+	switch level {
+	case Baseline:
+		c.baseline(spec)
+	case Restricted:
+		c.baseline(spec)
+		c.restricted(spec)
+	}
+	return c.violations
+}
+
+type checker struct {
+	violations []Violation
+}
+
+func (c *checker) addf(field, format string, args ...any) {
+	c.violations = append(c.violations, Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) baseline(spec *k8s.PodSpec) {
+	if psc := spec.SecurityContext; psc != nil && psc.SeccompProfile != nil && psc.SeccompProfile.Type == "Unconfined" {
+		c.addf("securityContext.seccompProfile.type", "le profil seccomp Unconfined est interdit")
+	}
+	for _, vol := range spec.Volumes {
+		if vol.HostPath != nil {
+			c.addf(fmt.Sprintf("volumes[%s].hostPath", vol.Name), "les volumes hostPath sont interdits (%s)", vol.HostPath.Path)
+		}
+	}
+
+	for _, ctr := range containers(spec) {
+		sc := ctr.SecurityContext
+		if sc == nil || sc.Capabilities == nil {
+			continue
+		}
+		for _, capability := range sc.Capabilities.Add {
+			if !slices.Contains(baselineCapabilities, capability) {
+				c.addf(ctr.field("securityContext.capabilities.add"), "la capability %s est interdite", capability)
+			}
+		}
+	}
+}
+
+func (c *checker) restricted(spec *k8s.PodSpec) {
+	for _, vol := range spec.Volumes {
+		if vol.PersistentVolumeClaim == nil && vol.EmptyDir == nil {
+			c.addf(fmt.Sprintf("volumes[%s]", vol.Name), "seuls les volumes persistentVolumeClaim et emptyDir sont autorisés")
+		}
+	}
+
+	psc := spec.SecurityContext
+	if psc == nil {
+		psc = &k8s.PodSecurityContext{}
+	}
+	if psc.RunAsUser != nil && *psc.RunAsUser == 0 {
+		c.addf("securityContext.runAsUser", "l'exécution en root (0) est interdite")
+	}
+	podNonRoot := psc.RunAsNonRoot != nil && *psc.RunAsNonRoot
+	if psc.SeccompProfile == nil || (psc.SeccompProfile.Type != "RuntimeDefault" && psc.SeccompProfile.Type != "Localhost") {
+		c.addf("securityContext.seccompProfile.type", "doit valoir RuntimeDefault ou Localhost")
+	}
+
+	podReported := false
+	for _, ctr := range containers(spec) {
+		sc := ctr.SecurityContext
+		if sc == nil {
+			sc = &k8s.SecurityContext{}
+		}
+
+		// Un conteneur peut surcharger l'identité définie au niveau du pod
+		switch {
+		case sc.RunAsNonRoot != nil:
+			if !*sc.RunAsNonRoot {
+				c.addf(ctr.field("securityContext.runAsNonRoot"), "doit valoir true")
+			}
+		case !podNonRoot && !podReported:
+			c.addf("securityContext.runAsNonRoot", "doit valoir true : définir runAsUser ou PUID")
+			podReported = true
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			c.addf(ctr.field("securityContext.runAsUser"), "l'exécution en root (0) est interdite")
+		}
+
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			c.addf(ctr.field("securityContext.allowPrivilegeEscalation"), "doit valoir false")
+		}
+
+		var caps k8s.Capabilities
+		if sc.Capabilities != nil {
+			caps = *sc.Capabilities
+		}
+		if !slices.Contains(caps.Drop, "ALL") {
+			c.addf(ctr.field("securityContext.capabilities.drop"), "doit contenir ALL")
+		}
+		for _, capability := range caps.Add {
+			if capability != "NET_BIND_SERVICE" && slices.Contains(baselineCapabilities, capability) {
+				c.addf(ctr.field("securityContext.capabilities.add"), "seule NET_BIND_SERVICE peut être ajoutée (obtenu: %s)", capability)
+			}
+		}
+	}
+}
+
+// podContainer associe un conteneur à la liste du pod qui le déclare.
+type podContainer struct {
+	list string // initContainers ou containers
+	k8s.Container
+}
+
+func (c podContainer) field(path string) string {
+	return fmt.Sprintf("%s[%s].%s", c.list, c.Name, path)
+}
+
+// containers retourne les sidecars et conteneurs d'init puis les conteneurs
+// principaux, soumis aux mêmes règles.
+func containers(spec *k8s.PodSpec) []podContainer {
+	var all []podContainer
+	for _, ctr := range spec.InitContainers {
+		all = append(all, podContainer{"initContainers", ctr})
+	}
+	for _, ctr := range spec.Containers {
+		all = append(all, podContainer{"containers", ctr})
+	}
+	return all
+}
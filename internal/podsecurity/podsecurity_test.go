@@ -0,0 +1,116 @@
+package podsecurity
+
+import (
+	"reflect"
+	"testing"
+
+	"teleflix/internal/k8s"
+)
+
+func int64Ptr(i int64) *int64 { return &i }
+func boolPtr(b bool) *bool    { return &b }
+
+// hardenedPod reproduit le pod généré par défaut pour un service linuxserver.io.
+func hardenedPod() *k8s.PodSpec {
+	return &k8s.PodSpec{
+		SecurityContext: &k8s.PodSecurityContext{
+			RunAsUser:      int64Ptr(1000),
+			RunAsGroup:     int64Ptr(1000),
+			RunAsNonRoot:   boolPtr(true),
+			FSGroup:        int64Ptr(1000),
+			SeccompProfile: &k8s.SeccompProfile{Type: "RuntimeDefault"},
+		},
+		Containers: []k8s.Container{{
+			Name: "sonarr",
+			SecurityContext: &k8s.SecurityContext{
+				AllowPrivilegeEscalation: boolPtr(false),
+				Capabilities:             &k8s.Capabilities{Drop: []string{"ALL"}},
+			},
+		}},
+		Volumes: []k8s.Volume{
+			{Name: "config", VolumeSource: k8s.VolumeSource{PersistentVolumeClaim: &k8s.PersistentVolumeClaimVolumeSource{ClaimName: "sonarr-config-pvc"}}},
+			{Name: "tmp", VolumeSource: k8s.VolumeSource{EmptyDir: &k8s.EmptyDirVolumeSource{}}},
+		},
+	}
+}
+
+func fields(violations []Violation) []string {
+	var out []string
+	for _, v := range violations {
+		out = append(out, v.Field)
+	}
+	return out
+}
+
+func TestHardenedPodIsRestricted(t *testing.T) {
+	if v := Check(Restricted, hardenedPod()); len(v) != 0 {
+		t.Fatalf("aucune violation attendue, obtenu %v", v)
+	}
+}
+
+func TestCheckLevels(t *testing.T) {
+	// Pod root avec NET_ADMIN (ex: client VPN) et SETUID
+	pod := hardenedPod()
+	pod.SecurityContext.RunAsUser = int64Ptr(0)
+	pod.SecurityContext.RunAsNonRoot = nil
+	pod.Containers[0].SecurityContext.Capabilities.Add = []string{"NET_ADMIN", "SETUID"}
+
+	tests := []struct {
+		level string
+		want  []string
+	}{
+		{Privileged, nil},
+		{Baseline, []string{
+			"containers[sonarr].securityContext.capabilities.add",
+		}},
+		{Restricted, []string{
+			"containers[sonarr].securityContext.capabilities.add",
+			"securityContext.runAsUser",
+			"securityContext.runAsNonRoot",
+			"containers[sonarr].securityContext.capabilities.add",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			if got := fields(Check(tt.level, pod)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%s) = %v, attendu %v", tt.level, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSidecarOverrides(t *testing.T) {
+	// Sidecar VPN : root, NET_ADMIN et périphérique TUN de l'hôte
+	pod := hardenedPod()
+	pod.InitContainers = []k8s.Container{{
+		Name: "vpn",
+		SecurityContext: &k8s.SecurityContext{
+			RunAsUser:                int64Ptr(0),
+			RunAsNonRoot:             boolPtr(false),
+			AllowPrivilegeEscalation: boolPtr(false),
+			Capabilities:             &k8s.Capabilities{Add: []string{"NET_ADMIN"}},
+		},
+	}}
+	pod.Volumes = append(pod.Volumes, k8s.Volume{
+		Name:         "tun",
+		VolumeSource: k8s.VolumeSource{HostPath: &k8s.HostPathVolumeSource{Path: "/dev/net/tun"}},
+	})
+
+	want := []string{
+		"volumes[tun].hostPath",
+		"initContainers[vpn].securityContext.capabilities.add",
+	}
+	if got := fields(Check(Baseline, pod)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Check(baseline) = %v, attendu %v", got, want)
+	}
+
+	want = append(want,
+		"volumes[tun]",
+		"initContainers[vpn].securityContext.runAsNonRoot",
+		"initContainers[vpn].securityContext.runAsUser",
+		"initContainers[vpn].securityContext.capabilities.drop",
+	)
+	if got := fields(Check(Restricted, pod)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Check(restricted) = %v, attendu %v", got, want)
+	}
+}